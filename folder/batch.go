@@ -0,0 +1,85 @@
+package folder
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+// OperationKind names the driver method an Operation stands for.
+type OperationKind int
+
+const (
+	// OpMove moves the folder at Path under the folder at Dst, as MoveFolderByPath.
+	OpMove OperationKind = iota
+	// OpCreate creates a folder called Name under the folder at Path, as CreateFolder.
+	OpCreate
+	// OpDelete deletes the folder at Path following Policy, as DeleteFolder.
+	OpDelete
+)
+
+// Operation is a single step of a batch, only the fields its Kind uses are read.
+type Operation struct {
+	Kind   OperationKind
+	OrgID  uuid.UUID
+	Path   string
+	Dst    string
+	Name   string
+	Policy DeletePolicy
+	Checks []WriteOption // preconditions for moves and deletes, see IfVersion
+}
+
+// OperationResult is what a single step of a batch returned.
+type OperationResult struct {
+	Folders []Folder     // the org's folders after a move, or the created folder
+	Deleted DeleteResult // what a delete changed
+	Err     error
+}
+
+/*
+Runs the operations against a copy of the driver's state, so each one is
+validated against the changes made before it, and only swaps the copy in when
+all of them succeed. On failure the failing operation's error is returned,
+its result carries the same error and the operations after it are marked as
+skipped.
+*/
+func (f *driver) ApplyBatch(ops []Operation) (_ []OperationResult, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("ApplyBatch", &err)
+
+	working := f.clone()
+	working.origin = newChangeSet()
+	results := make([]OperationResult, len(ops))
+
+	for i, op := range ops {
+		results[i] = working.apply(op)
+		if err := results[i].Err; err != nil {
+			for j := i + 1; j < len(ops); j++ {
+				results[j].Err = errors.New(ErrBatchAborted)
+			}
+			return results, err
+		}
+	}
+
+	f.adopt(working)
+	return results, nil
+}
+
+func (f *driver) apply(op Operation) OperationResult {
+	switch op.Kind {
+	case OpMove:
+		folders, err := f.MoveFolderByPath(op.OrgID, op.Path, op.Dst, op.Checks...)
+		return OperationResult{Folders: folders, Err: err}
+	case OpCreate:
+		created, err := f.CreateFolder(op.OrgID, op.Path, op.Name)
+		if err != nil {
+			return OperationResult{Folders: []Folder{}, Err: err}
+		}
+		return OperationResult{Folders: []Folder{created}}
+	case OpDelete:
+		deleted, err := f.DeleteFolder(op.OrgID, op.Path, op.Policy, op.Checks...)
+		return OperationResult{Deleted: deleted, Err: err}
+	}
+	return OperationResult{Err: errors.New(ErrUnknownOperation)}
+}
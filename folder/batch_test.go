@@ -0,0 +1,117 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_ApplyBatch(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+	})
+	tests := [...]struct {
+		name string
+		ops []folder.Operation
+		wantPaths []string
+		wantErrs []error
+		wantErr error
+	} {
+		{
+			name: "Later operations see earlier ones",
+			ops: []folder.Operation {
+				{Kind: folder.OpCreate, OrgID: orgID, Path: "C", Name: "D"},
+				{Kind: folder.OpMove, OrgID: orgID, Path: "A", Dst: "C.D"},
+				{Kind: folder.OpDelete, OrgID: orgID, Path: "C.D.A.B"},
+			},
+			wantPaths: []string{"C", "C.D", "C.D.A"},
+			wantErrs: []error{nil, nil, nil},
+		},
+		{
+			name: "Failure part way leaves everything untouched",
+			ops: []folder.Operation {
+				{Kind: folder.OpCreate, OrgID: orgID, Path: "A", Name: "E"},
+				{Kind: folder.OpMove, OrgID: orgID, Path: "C", Dst: "A.E"},
+				{Kind: folder.OpMove, OrgID: orgID, Path: "A", Dst: "A.E.C"},
+				{Kind: folder.OpDelete, OrgID: orgID, Path: "A.B"},
+			},
+			wantPaths: []string{"A", "A.B", "C"},
+			wantErrs: []error {
+				nil,
+				nil,
+				errors.New(folder.ErrSourceToChild),
+				errors.New(folder.ErrBatchAborted),
+			},
+			wantErr: errors.New(folder.ErrSourceToChild),
+		},
+		{
+			name: "Failure depending on an earlier operation",
+			ops: []folder.Operation {
+				{Kind: folder.OpDelete, OrgID: orgID, Path: "A", Policy: folder.DeleteRecursive},
+				{Kind: folder.OpMove, OrgID: orgID, Path: "C", Dst: "A"},
+			},
+			wantPaths: []string{"A", "A.B", "C"},
+			wantErrs: []error{nil, errors.New(folder.ErrDestNotExist)},
+			wantErr: errors.New(folder.ErrDestNotExist),
+		},
+		{
+			name: "Unknown operation",
+			ops: []folder.Operation {
+				{Kind: folder.OperationKind(7), OrgID: orgID, Path: "A"},
+			},
+			wantPaths: []string{"A", "A.B", "C"},
+			wantErrs: []error{errors.New(folder.ErrUnknownOperation)},
+			wantErr: errors.New(folder.ErrUnknownOperation),
+		},
+		{
+			name: "Empty batch",
+			wantPaths: []string{"A", "A.B", "C"},
+			wantErrs: []error{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+			results, err := f.ApplyBatch(tt.ops)
+			assert.Equal(t, tt.wantErr, err)
+
+			errs := []error{}
+			for _, res := range results {
+				errs = append(errs, res.Err)
+			}
+			assert.Equal(t, tt.wantErrs, errs)
+
+			paths := []string{}
+			for _, folder := range f.GetFoldersByOrgID(orgID) {
+				paths = append(paths, folder.Paths)
+			}
+			assert.ElementsMatch(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+func Test_folder_ApplyBatch_Results(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+	})
+	results, err := f.ApplyBatch([]folder.Operation {
+		{Kind: folder.OpCreate, OrgID: orgID, Path: "A", Name: "B"},
+		{Kind: folder.OpDelete, OrgID: orgID, Path: "A.B"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	created := results[0].Folders
+	assert.Len(t, created, 1)
+	assert.Equal(t, "A.B", created[0].Paths)
+	assert.Equal(t, created, results[1].Deleted.Removed)
+}
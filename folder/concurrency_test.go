@@ -0,0 +1,223 @@
+package folder_test
+
+import (
+	"sync"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+These tests are meant to be run with the race detector:
+
+	go test -race ./...
+
+Writers keep moving a subtree back and forth while readers check that they
+never see it half moved.
+*/
+
+const rounds = 200
+
+func concurrencyFolders(orgID uuid.UUID) []folder.Folder {
+	return []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "D"},
+	}
+}
+
+/* Moves B between A and D, rounds times */
+func shuttle(t *testing.T, f folder.IDriver, orgID uuid.UUID) {
+	for n := range rounds {
+		src, dst := "A.B", "D"
+		if n % 2 == 1 {
+			src, dst = "D.B", "A"
+		}
+		_, err := f.MoveFolderByPath(orgID, src, dst)
+		assert.NoError(t, err)
+	}
+}
+
+/* Checks C always sits right below B, wherever B is */
+func assertWhole(t *testing.T, folders []folder.Folder) {
+	paths := make(map[string]string)
+	for _, folder := range folders {
+		paths[folder.Name] = folder.Paths
+	}
+	assert.Equal(t, paths["B"] + ".C", paths["C"])
+}
+
+func Test_folder_Concurrent(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	tests := [...]struct {
+		name string
+		read func(t *testing.T, f folder.IDriver)
+	} {
+		{
+			name: "GetFoldersByOrgID",
+			read: func(t *testing.T, f folder.IDriver) {
+				assertWhole(t, f.GetFoldersByOrgID(orgID))
+			},
+		},
+		{
+			name: "GetAllChildFolders",
+			read: func(t *testing.T, f folder.IDriver) {
+				res, err := f.GetAllChildFolders(orgID, "B")
+				assert.NoError(t, err)
+				assert.Len(t, res, 1)
+			},
+		},
+		{
+			name: "GetChildFolders",
+			read: func(t *testing.T, f folder.IDriver) {
+				a, errA := f.GetChildFolders(orgID, "A", folder.ChildQueryOptions{})
+				d, errD := f.GetChildFolders(orgID, "D", folder.ChildQueryOptions{})
+				assert.NoError(t, errA)
+				assert.NoError(t, errD)
+				// B may move between the two calls, but is never split
+				assert.Contains(t, []int{0, 2}, len(a))
+				assert.Contains(t, []int{0, 2}, len(d))
+			},
+		},
+		{
+			name: "GetFolderByID",
+			read: func(t *testing.T, f folder.IDriver) {
+				id := folder.DeriveFolderID(orgID, "A.B.C")
+				c, err := f.GetFolderByID(id)
+				assert.NoError(t, err)
+				ancestors, err := f.GetAncestors(orgID, c.Paths)
+				if err == nil {
+					assert.Len(t, ancestors, 2)
+				}
+			},
+		},
+		{
+			name: "Snapshot",
+			read: func(t *testing.T, f folder.IDriver) {
+				assertWhole(t, f.Snapshot().GetFoldersByOrgID(orgID))
+			},
+		},
+		{
+			name: "History",
+			read: func(t *testing.T, f folder.IDriver) {
+				assert.LessOrEqual(t, len(f.History()), folder.DefaultHistoryLimit)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(concurrencyFolders(orgID))
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				shuttle(t, f, orgID)
+			}()
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range rounds {
+						tt.read(t, f)
+					}
+				}()
+			}
+			wg.Wait()
+
+			assertWhole(t, f.GetFoldersByOrgID(orgID))
+		})
+	}
+}
+
+func Test_folder_Concurrent_Writers(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver(concurrencyFolders(orgID))
+	sub := f.Subscribe(rounds * 10)
+
+	var wg sync.WaitGroup
+	writers := []func() {
+		func() { shuttle(t, f, orgID) },
+		func() {
+			for range rounds {
+				created, err := f.CreateFolder(orgID, "", "E")
+				assert.NoError(t, err)
+				_, err = f.DeleteFolder(orgID, created.Paths, folder.DeleteIfEmpty)
+				assert.NoError(t, err)
+			}
+		},
+		func() {
+			for range rounds {
+				_, err := f.ApplyBatch([]folder.Operation {
+					{Kind: folder.OpCreate, OrgID: orgID, Path: "A", Name: "F"},
+					{Kind: folder.OpDelete, OrgID: orgID, Path: "A.F"},
+				})
+				assert.NoError(t, err)
+			}
+		},
+		func() {
+			for range rounds {
+				tx := f.Begin()
+				_, err := tx.CreateFolder(orgID, "", "G")
+				assert.NoError(t, err)
+				// other writers usually get in first, either outcome is fine
+				if tx.Commit() == nil {
+					_, err = f.DeleteFolder(orgID, "G", folder.DeleteIfEmpty)
+					assert.NoError(t, err)
+				}
+			}
+		},
+	}
+	for _, write := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			write()
+		}()
+	}
+	wg.Wait()
+	sub.Close()
+
+	assertWhole(t, f.GetFoldersByOrgID(orgID))
+	for event := range sub.Events() {
+		assert.NotEmpty(t, event.Changes)
+	}
+}
+
+func Test_folder_Concurrent_Undo(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver(concurrencyFolders(orgID))
+	_, err := f.MoveFolderByPath(orgID, "A.B", "D")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range rounds {
+			_, err := f.Undo()
+			assert.NoError(t, err)
+			_, err = f.Redo()
+			assert.NoError(t, err)
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				assertWhole(t, f.GetFoldersByOrgID(orgID))
+				f.History()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, "D.B", f.GetFoldersByOrgID(orgID)[1].Paths)
+}
@@ -0,0 +1,133 @@
+package folder
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/lucasepe/codename"
+)
+
+// ConflictStrategy decides what happens when a folder would land next to a
+// sibling that already has its name.
+type ConflictStrategy int
+
+const (
+	// ConflictFail rejects the operation with ErrFolderNameConflict.
+	ConflictFail ConflictStrategy = iota
+	// ConflictSuffix appends the lowest free number to the name, e.g. "reports2".
+	ConflictSuffix
+	// ConflictCodename picks a fresh random codename, the way GenerateData does.
+	ConflictCodename
+)
+
+// CopyOptions tunes CopyFolder, the zero value copies within the source org
+// and fails on a name conflict.
+type CopyOptions struct {
+	OnConflict ConflictStrategy
+	// DstOrgID, when set, places the copy in this organisation instead of the
+	// source folder's one.
+	DstOrgID uuid.UUID
+}
+
+func (f *driver) CopyFolder(orgID uuid.UUID, src string, dst string, opts CopyOptions) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("CopyFolder", &err)
+
+	if opts.OnConflict < ConflictFail || opts.OnConflict > ConflictCodename {
+		return []Folder{}, errors.New(ErrUnknownConflictStrategy)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	source, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	childFolders, err := idx.descendants(source.Paths)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	dstOrgID := orgID
+	if !opts.DstOrgID.IsNil() {
+		dstOrgID = opts.DstOrgID
+	}
+	dstIdx, exists := f.orgs[dstOrgID]
+	if !exists {
+		dstIdx = buildOrgIndex(nil, nil)
+	}
+	if dst != "" {
+		if _, err := dstIdx.resolvePath(dst, ErrDestNotExist); err != nil {
+			return []Folder{}, err
+		}
+	}
+
+	name, err := dstIdx.freeName(dst, source.Name, opts.OnConflict)
+	if err != nil {
+		return []Folder{}, err
+	}
+	newPath := name
+	if dst != "" {
+		newPath = dst + "." + name
+	}
+
+	copies := []Folder{{
+		Id:       uuid.Must(uuid.NewV4()),
+		Name:     name,
+		OrgId:    dstOrgID,
+		Paths:    newPath,
+		Position: dstIdx.nextPosition(dst),
+	}}
+	for _, f := range childFolders {
+		copies = append(copies, Folder{
+			Id:       uuid.Must(uuid.NewV4()),
+			Name:     f.Name,
+			OrgId:    dstOrgID,
+			Paths:    newPath + f.Paths[len(source.Paths):], // keeps the leading '.'
+			Position: f.Position,
+		})
+	}
+	f.insertFolders(dstOrgID, copies)
+
+	return copies, nil
+}
+
+/* Picks the name a folder called name gets under parent, following the conflict strategy */
+func (idx *orgIndex) freeName(parent string, name string, strategy ConflictStrategy) (string, error) {
+	prefix := ""
+	if parent != "" {
+		prefix = parent + "."
+	}
+	if _, exists := idx.byPath.Get(prefix + name); !exists {
+		return name, nil
+	}
+
+	switch strategy {
+	case ConflictSuffix:
+		for n := 2; ; n++ {
+			candidate := name + strconv.Itoa(n)
+			if _, exists := idx.byPath.Get(prefix + candidate); !exists {
+				return candidate, nil
+			}
+		}
+	case ConflictCodename:
+		rng, err := codename.DefaultRNG()
+		if err != nil {
+			return "", err
+		}
+		for {
+			// codenames are hyphenated, which isn't a valid label character
+			candidate := strings.ReplaceAll(codename.Generate(rng, 0), "-", "")
+			if _, exists := idx.byPath.Get(prefix + candidate); !exists && ValidateFolderName(candidate) {
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", errors.New(ErrFolderNameConflict + " " + prefix + name)
+}
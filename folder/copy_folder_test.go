@@ -0,0 +1,168 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+/* IDs of copies are random, so compare everything else */
+func withoutIDs(folders []folder.Folder) []folder.Folder {
+	res := make([]folder.Folder, len(folders))
+	for i, f := range folders {
+		f.Id = uuid.Nil
+		res[i] = f
+	}
+	return res
+}
+
+func Test_folder_CopyFolder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder {
+		{Name: "template", OrgId: orgID, Paths: "template"},
+		{Name: "B", OrgId: orgID, Paths: "template.B"},
+		{Name: "C", OrgId: orgID, Paths: "template.B.C"},
+		{Name: "site", OrgId: orgID, Paths: "site", Position: 1},
+		{Name: "template", OrgId: orgID, Paths: "site.template"},
+		{Name: "template2", OrgId: orgID, Paths: "site.template2", Position: 1},
+		{Name: "X", OrgId: otherOrgID, Paths: "X"},
+	}
+	tests := [...]struct {
+		name string
+		src string
+		dst string
+		opts folder.CopyOptions
+		wantFolders []folder.Folder
+		wantErr error
+	} {
+		{
+			name: "Copy subtree under another parent",
+			src: "template.B",
+			dst: "site",
+			wantFolders: []folder.Folder {
+				{Name: "B", OrgId: orgID, Paths: "site.B", Position: 2},
+				{Name: "C", OrgId: orgID, Paths: "site.B.C"},
+			},
+		},
+		{
+			name: "Copy into own subtree",
+			src: "template",
+			dst: "template.B.C",
+			wantFolders: []folder.Folder {
+				{Name: "template", OrgId: orgID, Paths: "template.B.C.template"},
+				{Name: "B", OrgId: orgID, Paths: "template.B.C.template.B"},
+				{Name: "C", OrgId: orgID, Paths: "template.B.C.template.B.C"},
+			},
+		},
+		{
+			name: "Conflict fails by default",
+			src: "template",
+			dst: "site",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrFolderNameConflict + " site.template"),
+		},
+		{
+			name: "Conflict resolved with the lowest free suffix",
+			src: "template",
+			dst: "site",
+			opts: folder.CopyOptions{OnConflict: folder.ConflictSuffix},
+			wantFolders: []folder.Folder {
+				{Name: "template3", OrgId: orgID, Paths: "site.template3", Position: 2},
+				{Name: "B", OrgId: orgID, Paths: "site.template3.B"},
+				{Name: "C", OrgId: orgID, Paths: "site.template3.B.C"},
+			},
+		},
+		{
+			name: "Conflict at the top level",
+			src: "template",
+			dst: "",
+			opts: folder.CopyOptions{OnConflict: folder.ConflictSuffix},
+			wantFolders: []folder.Folder {
+				{Name: "template2", OrgId: orgID, Paths: "template2", Position: 2},
+				{Name: "B", OrgId: orgID, Paths: "template2.B"},
+				{Name: "C", OrgId: orgID, Paths: "template2.B.C"},
+			},
+		},
+		{
+			name: "Copy into another organisation",
+			src: "template.B",
+			dst: "X",
+			opts: folder.CopyOptions{DstOrgID: otherOrgID},
+			wantFolders: []folder.Folder {
+				{Name: "B", OrgId: otherOrgID, Paths: "X.B"},
+				{Name: "C", OrgId: otherOrgID, Paths: "X.B.C"},
+			},
+		},
+		{
+			name: "Destination must exist in the target organisation",
+			src: "template.B",
+			dst: "site",
+			opts: folder.CopyOptions{DstOrgID: otherOrgID},
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrDestNotExist),
+		},
+		{
+			name: "Non-existent source",
+			src: "template.D",
+			dst: "site",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrSourceNotExists),
+		},
+		{
+			name: "Unknown conflict strategy",
+			src: "template",
+			dst: "site",
+			opts: folder.CopyOptions{OnConflict: folder.ConflictStrategy(42)},
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrUnknownConflictStrategy),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.CopyFolder(orgID, tt.src, tt.dst, tt.opts)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Equal(t, tt.wantFolders, get)
+				assert.Equal(t, withIDs(folders[:6]), f.GetFoldersByOrgID(orgID))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFolders, withoutIDs(get))
+
+			// copies are new folders, the originals are untouched
+			for _, c := range get {
+				assert.NotContains(t, withIDs(folders), c)
+				byID, err := f.GetFolderByID(c.Id)
+				assert.NoError(t, err)
+				assert.Equal(t, c, byID)
+			}
+			children, err := f.GetAllChildFoldersByPath(get[0].OrgId, get[0].Paths)
+			assert.NoError(t, err)
+			assert.Equal(t, get[1:], children)
+		})
+	}
+}
+
+func Test_folder_CopyFolderCodename(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+	})
+
+	get, err := f.CopyFolder(orgID, "A", "", folder.CopyOptions{OnConflict: folder.ConflictCodename})
+	assert.NoError(t, err)
+	assert.Len(t, get, 2)
+	assert.NotEqual(t, "A", get[0].Name)
+	assert.True(t, folder.ValidateFolderName(get[0].Name))
+	assert.Equal(t, get[0].Name, get[0].Paths)
+	assert.Equal(t, get[0].Name + ".B", get[1].Paths)
+}
@@ -0,0 +1,75 @@
+package folder
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// ValidateFolderName checks a single label, which follows the same rules as a
+// path but may not contain the separator.
+func ValidateFolderName(name string) bool {
+	return ValidateFilePath(name) && !strings.Contains(name, ".")
+}
+
+func (f *driver) CreateFolder(orgID uuid.UUID, parentPath string, name string) (_ Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("CreateFolder", &err)
+
+	if orgID.IsNil() {
+		return Folder{}, errors.New(ErrInvalidOrgID)
+	}
+	if !ValidateFolderName(name) {
+		return Folder{}, errors.New(ErrInvalidFolderName + " " + name)
+	}
+
+	path := name
+	if parentPath != "" {
+		if !ValidateFilePath(parentPath) {
+			return Folder{}, errors.New(ErrInvalidFilePath)
+		}
+		path = parentPath + "." + name
+	}
+
+	idx, exists := f.orgs[orgID]
+	if !exists {
+		idx = buildOrgIndex(nil, nil)
+	}
+
+	if parentPath != "" {
+		seq, exists := idx.byPath.Get(parentPath)
+		if !exists {
+			return Folder{}, errors.New(ErrUnseenFolder + " " + path + " for " + lastLabel(parentPath))
+		}
+		if idx.errs[seq] != nil {
+			return Folder{}, idx.errs[seq]
+		}
+	}
+	if _, exists := idx.byPath.Get(path); exists {
+		return Folder{}, errors.New(ErrFolderNameConflict + " " + path)
+	}
+
+	folder := Folder{
+		Id:       uuid.Must(uuid.NewV4()),
+		Name:     name,
+		OrgId:    orgID,
+		Paths:    path,
+		Position: idx.nextPosition(parentPath),
+	}
+
+	f.insertFolders(orgID, []Folder{folder})
+
+	return folder, nil
+}
+
+/* Adds new folders to the end of an organisation, patching its index once */
+func (f *driver) insertFolders(orgID uuid.UUID, newFolders []Folder) {
+	records := make([]folderRecord, len(newFolders))
+	for i, folder := range newFolders {
+		records[i] = folderRecord{folder, f.nextSeq}
+		f.nextSeq++
+	}
+	f.setRecords(orgID, records, nil)
+}
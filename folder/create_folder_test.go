@@ -0,0 +1,155 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_CreateFolder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+	}
+	tests := [...]struct {
+		name string
+		orgID uuid.UUID
+		parentPath string
+		folderName string
+		wantPath string
+		wantErr error
+	} {
+		{
+			name: "Child folder",
+			orgID: orgID,
+			parentPath: "A.B",
+			folderName: "C",
+			wantPath: "A.B.C",
+		},
+		{
+			name: "Root folder",
+			orgID: orgID,
+			parentPath: "",
+			folderName: "C",
+			wantPath: "C",
+		},
+		{
+			name: "Root folder in a new organisation",
+			orgID: otherOrgID,
+			parentPath: "",
+			folderName: "A",
+			wantPath: "A",
+		},
+		{
+			name: "Duplicate sibling",
+			orgID: orgID,
+			parentPath: "A",
+			folderName: "B",
+			wantErr: errors.New(folder.ErrFolderNameConflict + " A.B"),
+		},
+		{
+			name: "Duplicate root",
+			orgID: orgID,
+			parentPath: "",
+			folderName: "A",
+			wantErr: errors.New(folder.ErrFolderNameConflict + " A"),
+		},
+		{
+			name: "Missing parent",
+			orgID: orgID,
+			parentPath: "A.C",
+			folderName: "D",
+			wantErr: errors.New(folder.ErrUnseenFolder + " A.C.D for C"),
+		},
+		{
+			name: "Parent in another organisation",
+			orgID: otherOrgID,
+			parentPath: "A",
+			folderName: "C",
+			wantErr: errors.New(folder.ErrUnseenFolder + " A.C for A"),
+		},
+		{
+			name: "Name containing separator",
+			orgID: orgID,
+			parentPath: "A",
+			folderName: "C.D",
+			wantErr: errors.New(folder.ErrInvalidFolderName + " C.D"),
+		},
+		{
+			name: "Name with special character",
+			orgID: orgID,
+			parentPath: "A",
+			folderName: "C%",
+			wantErr: errors.New(folder.ErrInvalidFolderName + " C%"),
+		},
+		{
+			name: "Empty name",
+			orgID: orgID,
+			parentPath: "A",
+			folderName: "",
+			wantErr: errors.New(folder.ErrInvalidFolderName + " "),
+		},
+		{
+			name: "Invalid parent path",
+			orgID: orgID,
+			parentPath: "A.",
+			folderName: "C",
+			wantErr: errors.New(folder.ErrInvalidFilePath),
+		},
+		{
+			name: "Invalid nil orgID",
+			orgID: uuid.Nil,
+			parentPath: "",
+			folderName: "C",
+			wantErr: errors.New(folder.ErrInvalidOrgID),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.CreateFolder(tt.orgID, tt.parentPath, tt.folderName)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Equal(t, folder.Folder{}, get)
+				assert.Len(t, f.GetFoldersByOrgID(orgID), len(folders))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, get.Id.IsNil())
+			assert.Equal(t, tt.folderName, get.Name)
+			assert.Equal(t, tt.orgID, get.OrgId)
+			assert.Equal(t, tt.wantPath, get.Paths)
+
+			// visible straight away
+			assert.Contains(t, f.GetFoldersByOrgID(tt.orgID), get)
+			byID, err := f.GetFolderByID(get.Id)
+			assert.NoError(t, err)
+			assert.Equal(t, get, byID)
+		})
+	}
+}
+
+func Test_folder_CreateFolderVisibleAsChild(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder{})
+
+	a, err := f.CreateFolder(orgID, "", "A")
+	assert.NoError(t, err)
+	b, err := f.CreateFolder(orgID, "A", "B")
+	assert.NoError(t, err)
+	c, err := f.CreateFolder(orgID, "A.B", "C")
+	assert.NoError(t, err)
+
+	children, err := f.GetAllChildFolders(orgID, "A")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{b, c}, children)
+	assert.Equal(t, []folder.Folder{a, b, c}, f.GetFoldersByOrgID(orgID))
+}
@@ -0,0 +1,122 @@
+package folder
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+// DeletePolicy decides what DeleteFolder does with the child folders of the
+// folder being deleted.
+type DeletePolicy int
+
+const (
+	// DeleteIfEmpty refuses to delete a folder that has child folders.
+	DeleteIfEmpty DeletePolicy = iota
+	// DeleteRecursive deletes the folder along with its whole subtree.
+	DeleteRecursive
+	// DeleteReparent hands the child folders over to the deleted folder's
+	// parent, or makes them roots when a root folder is deleted.
+	DeleteReparent
+)
+
+// DeleteResult lists what a DeleteFolder call changed.
+type DeleteResult struct {
+	Removed []Folder // folders no longer held by the driver
+	Moved   []Folder // reparented folders, and siblings shifted to make room, as they are now
+}
+
+func (f *driver) DeleteFolder(orgID uuid.UUID, path string, policy DeletePolicy, opts ...WriteOption) (_ DeleteResult, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("DeleteFolder", &err)
+
+	if policy < DeleteIfEmpty || policy > DeleteReparent {
+		return DeleteResult{}, errors.New(ErrUnknownDeletePolicy)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	target, err := idx.resolvePath(path, ErrFolderNotExist)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+	if err := checkVersion(target, opts); err != nil {
+		return DeleteResult{}, err
+	}
+	childFolders, err := idx.descendants(target.Paths)
+	if err != nil {
+		return DeleteResult{}, err
+	}
+
+	res := DeleteResult{Removed: []Folder{target}, Moved: []Folder{}}
+	removing := map[string]bool{target.Paths: true}
+	updatingFolders := make(map[string]Folder) // map of old path : Folder
+
+	switch policy {
+	case DeleteIfEmpty:
+		if len(childFolders) > 0 {
+			return DeleteResult{}, errors.New(ErrFolderNotEmpty)
+		}
+	case DeleteRecursive:
+		for _, f := range childFolders {
+			removing[f.Paths] = true
+		}
+		res.Removed = append(res.Removed, childFolders...)
+	case DeleteReparent:
+		// the children take the deleted folder's place, same rewrite as MoveFolder
+		newParentPath := parentPath(target.Paths)
+		for _, f := range childFolders {
+			oldPath := f.Paths
+			f.Paths = f.Paths[len(target.Paths)+1:] // (+1) due to extra '.'
+			if newParentPath != "" {
+				f.Paths = newParentPath + "." + f.Paths
+			}
+			updatingFolders[oldPath] = f
+		}
+
+		// only the children can clash, with the deleted folder's siblings, as
+		// everything below them moves up along with them
+		for _, seq := range idx.childSeqs(target.Paths) {
+			newPath := updatingFolders[idx.folder(seq).Paths].Paths
+			if _, exists := idx.byPath.Get(newPath); exists && newPath != target.Paths {
+				return DeleteResult{}, errors.New(ErrFolderNameConflict + " " + newPath)
+			}
+		}
+
+		// and its spot among the siblings, later siblings shift along if needed
+		position := target.Position
+		for _, seq := range idx.childSeqs(target.Paths) {
+			oldPath := idx.folder(seq).Paths
+			child := updatingFolders[oldPath]
+			child.Position = position
+			updatingFolders[oldPath] = child
+			position++
+		}
+		for _, seq := range idx.childSeqs(newParentPath) {
+			sibling := idx.folder(seq)
+			if sibling.Position <= target.Position || sibling.Position >= position {
+				continue
+			}
+			sibling.Position = position
+			updatingFolders[sibling.Paths] = sibling
+			position++
+		}
+	}
+
+	removed := make([]int, 0, len(removing))
+	for path := range removing {
+		seq, _ := idx.byPath.Get(path)
+		removed = append(removed, seq)
+	}
+	records := idx.records(updatingFolders)
+	f.writeFolders(orgID, records, removed)
+	for _, record := range records {
+		res.Moved = append(res.Moved, record.folder)
+	}
+	res.Moved = f.refresh(orgID, res.Moved)
+
+	return res, nil
+}
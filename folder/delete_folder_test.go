@@ -0,0 +1,220 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_DeleteFolder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "A.B.C.D"},
+		{Name: "E", OrgId: orgID, Paths: "A.B.E", Position: 1},
+		{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+		{Name: "G", OrgId: orgID, Paths: "C.G"},
+		{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+	}
+	tests := [...]struct {
+		name string
+		path string
+		policy folder.DeletePolicy
+		wantResult folder.DeleteResult
+		wantFolders []folder.Folder
+		wantErr error
+	} {
+		{
+			name: "Empty folder",
+			path: "A.F",
+			policy: folder.DeleteIfEmpty,
+			wantResult: folder.DeleteResult{
+				Removed: withIDs([]folder.Folder{{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1}}),
+				Moved: []folder.Folder{},
+			},
+			wantFolders: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+				{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+				{Name: "D", OrgId: orgID, Paths: "A.B.C.D"},
+				{Name: "E", OrgId: orgID, Paths: "A.B.E", Position: 1},
+				{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+				{Name: "G", OrgId: orgID, Paths: "C.G"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+			}),
+		},
+		{
+			name: "Folder with children refused",
+			path: "A.B",
+			policy: folder.DeleteIfEmpty,
+			wantErr: errors.New(folder.ErrFolderNotEmpty),
+			wantFolders: withIDs(folders),
+		},
+		{
+			name: "Recursive delete",
+			path: "A.B",
+			policy: folder.DeleteRecursive,
+			wantResult: folder.DeleteResult{
+				Removed: withIDs([]folder.Folder{
+					{Name: "B", OrgId: orgID, Paths: "A.B"},
+					{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+					{Name: "D", OrgId: orgID, Paths: "A.B.C.D"},
+					{Name: "E", OrgId: orgID, Paths: "A.B.E", Position: 1},
+				}),
+				Moved: []folder.Folder{},
+			},
+			wantFolders: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+				{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+				{Name: "G", OrgId: orgID, Paths: "C.G"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+			}),
+		},
+		{
+			name: "Reparent children into the deleted folder's place",
+			path: "A.B",
+			policy: folder.DeleteReparent,
+			wantResult: folder.DeleteResult{
+				Removed: withIDs([]folder.Folder{{Name: "B", OrgId: orgID, Paths: "A.B"}}),
+				Moved: []folder.Folder{
+					{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "A.C", Version: 1},
+					{Id: folder.DeriveFolderID(orgID, "A.B.C.D"), Name: "D", OrgId: orgID, Paths: "A.C.D", Version: 1},
+					{Id: folder.DeriveFolderID(orgID, "A.B.E"), Name: "E", OrgId: orgID, Paths: "A.E", Position: 1, Version: 1},
+					{Id: folder.DeriveFolderID(orgID, "A.F"), Name: "F", OrgId: orgID, Paths: "A.F", Position: 2, Version: 1},
+				},
+			},
+			wantFolders: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "A.C", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C.D"), Name: "D", OrgId: orgID, Paths: "A.C.D", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.E"), Name: "E", OrgId: orgID, Paths: "A.E", Position: 1, Version: 1},
+				{Name: "F", OrgId: orgID, Paths: "A.F", Position: 2, Version: 1},
+				{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+				{Name: "G", OrgId: orgID, Paths: "C.G"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+			}),
+		},
+		{
+			name: "Reparent children of a root onto the top level clashes",
+			path: "A",
+			policy: folder.DeleteReparent,
+			wantErr: errors.New(folder.ErrFolderNameConflict + " B"),
+			wantFolders: withIDs(folders),
+		},
+		{
+			name: "Reparent keeps the subtree below the children",
+			path: "A.B.C",
+			policy: folder.DeleteReparent,
+			wantResult: folder.DeleteResult{
+				Removed: withIDs([]folder.Folder{{Name: "C", OrgId: orgID, Paths: "A.B.C"}}),
+				Moved: []folder.Folder{
+					{Id: folder.DeriveFolderID(orgID, "A.B.C.D"), Name: "D", OrgId: orgID, Paths: "A.B.D", Version: 1},
+				},
+			},
+			wantFolders: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C.D"), Name: "D", OrgId: orgID, Paths: "A.B.D", Version: 1},
+				{Name: "E", OrgId: orgID, Paths: "A.B.E", Position: 1},
+				{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+				{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+				{Name: "G", OrgId: orgID, Paths: "C.G"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+			}),
+		},
+		{
+			name: "Reparent children of a root onto the top level",
+			path: "C",
+			policy: folder.DeleteReparent,
+			wantResult: folder.DeleteResult{
+				Removed: withIDs([]folder.Folder{{Name: "C", OrgId: orgID, Paths: "C", Position: 1}}),
+				Moved: []folder.Folder{
+					{Id: folder.DeriveFolderID(orgID, "C.G"), Name: "G", OrgId: orgID, Paths: "G", Position: 1, Version: 1},
+				},
+			},
+			wantFolders: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+				{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+				{Name: "D", OrgId: orgID, Paths: "A.B.C.D"},
+				{Name: "E", OrgId: orgID, Paths: "A.B.E", Position: 1},
+				{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+				{Id: folder.DeriveFolderID(orgID, "C.G"), Name: "G", OrgId: orgID, Paths: "G", Position: 1, Version: 1},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 2},
+			}),
+		},
+		{
+			name: "Non-existent folder",
+			path: "A.G",
+			policy: folder.DeleteRecursive,
+			wantErr: errors.New(folder.ErrFolderNotExist),
+			wantFolders: withIDs(folders),
+		},
+		{
+			name: "Unknown policy",
+			path: "A.F",
+			policy: folder.DeletePolicy(42),
+			wantErr: errors.New(folder.ErrUnknownDeletePolicy),
+			wantFolders: withIDs(folders),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.DeleteFolder(orgID, tt.path, tt.policy)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantResult, get)
+			assert.Equal(t, tt.wantFolders, f.GetFoldersByOrgID(orgID))
+		})
+	}
+}
+
+func Test_folder_DeleteFolderForgetsIDs(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder{{Name: "A", OrgId: orgID, Paths: "A"}})
+
+	res, err := f.DeleteFolder(orgID, "A", folder.DeleteIfEmpty)
+	assert.NoError(t, err)
+
+	_, err = f.GetFolderByID(res.Removed[0].Id)
+	assert.EqualError(t, err, folder.ErrFolderNotExist)
+	assert.Equal(t, []folder.Folder{}, f.GetFoldersByOrgID(orgID))
+
+	// the organisation can be started over
+	_, err = f.CreateFolder(orgID, "", "A")
+	assert.NoError(t, err)
+}
+
+func Test_folder_DeleteFolderReparentRepeatedNames(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "a", OrgId: orgID, Paths: "a"},
+		{Name: "x", OrgId: orgID, Paths: "a.x"},
+		{Name: "x", OrgId: orgID, Paths: "a.x.x"},
+		{Name: "x", OrgId: orgID, Paths: "a.x.x.x"},
+	})
+
+	// the subtree's old paths are all going away, they can't clash
+	_, err := f.DeleteFolder(orgID, "a.x", folder.DeleteReparent)
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder {
+		{Id: folder.DeriveFolderID(orgID, "a"), Name: "a", OrgId: orgID, Paths: "a"},
+		{Id: folder.DeriveFolderID(orgID, "a.x.x"), Name: "x", OrgId: orgID, Paths: "a.x", Version: 1},
+		{Id: folder.DeriveFolderID(orgID, "a.x.x.x"), Name: "x", OrgId: orgID, Paths: "a.x.x", Version: 1},
+	}, f.GetFoldersByOrgID(orgID))
+}
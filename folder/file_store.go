@@ -0,0 +1,88 @@
+package folder
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FilePerm is the permission data files are written with.
+const FilePerm fs.FileMode = 0o644
+
+/*
+FileStore keeps every folder in a single JSON file, in the same format as
+sample.json. The whole file is rewritten on each change, into a temporary file
+that is synced and then renamed over the old one, so a crash leaves either the
+old or the new state on disk and never a truncated file.
+*/
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a store for the file at path, which is created on the
+// first save.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load returns the folders in the file, none if it doesn't exist yet.
+func (s *FileStore) Load() ([]Folder, error) {
+	folders, err := LoadFoldersFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Folder{}, nil
+	}
+	return folders, err
+}
+
+// Save writes the folders of state to the file.
+func (s *FileStore) Save(event ChangeEvent, state *Snapshot) error {
+	return writeFileAtomic(s.path, MarshalJson(state.Folders()), FilePerm)
+}
+
+// OpenFileDriver opens a driver on a FileStore for the file at path.
+func OpenFileDriver(path string, opts ...Option) (IDriver, error) {
+	return Open(NewFileStore(path), opts...)
+}
+
+/* Replaces the file at path with data, through a synced temporary file in the same directory */
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+/* Makes a rename in dir durable */
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,79 @@
+package folder_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_OpenFileDriver(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "folders.json")
+
+	f, err := folder.OpenFileDriver(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{}, f.GetFoldersByOrgID(orgID))
+	for _, name := range []string{"A", "B", "C"} {
+		_, err := f.CreateFolder(orgID, "", name)
+		assert.NoError(t, err)
+	}
+	_, err = f.CreateFolder(orgID, "A", "D")
+	assert.NoError(t, err)
+	_, err = f.MoveFolder("D", "C")
+	assert.NoError(t, err)
+	_, err = f.MoveFolderBefore(orgID, "C", "A")
+	assert.NoError(t, err)
+
+	// every change is on disk already, a new driver picks up the same state
+	reopened, err := folder.OpenFileDriver(path)
+	assert.NoError(t, err)
+	assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+	children, err := reopened.GetAllChildFoldersByPath(orgID, "C")
+	assert.NoError(t, err)
+	assert.Len(t, children, 1)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, folder.FilePerm, info.Mode().Perm())
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1) // no temporary files left behind
+}
+
+func Test_folder_OpenFileDriver_SaveFails(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	dir := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, os.Mkdir(dir, 0o755))
+	path := filepath.Join(dir, "folders.json")
+
+	f, err := folder.OpenFileDriver(path)
+	assert.NoError(t, err)
+	_, err = f.CreateFolder(orgID, "", "A")
+	assert.NoError(t, err)
+	_, err = f.CreateFolder(orgID, "", "B")
+	assert.NoError(t, err)
+	before := f.GetFoldersByOrgID(orgID)
+
+	// the store can no longer write, so the move is undone and reported
+	assert.NoError(t, os.RemoveAll(dir))
+	_, err = f.MoveFolder("B", "A")
+	assert.Error(t, err)
+	assert.Equal(t, before, f.GetFoldersByOrgID(orgID))
+	assert.Len(t, f.History(), 2)
+}
+
+func Test_folder_OpenFileDriver_Corrupt(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "folders.json")
+	assert.NoError(t, os.WriteFile(path, []byte("[{"), folder.FilePerm))
+
+	f, err := folder.OpenFileDriver(path)
+	assert.Error(t, err)
+	assert.Nil(t, f)
+}
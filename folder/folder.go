@@ -1,6 +1,12 @@
 package folder
 
-import "github.com/gofrs/uuid"
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/gofrs/uuid"
+)
 
 type IDriver interface {
 	// GetFoldersByOrgID returns all folders that belong to a specific orgID.
@@ -13,21 +19,318 @@ type IDriver interface {
 	// component 2
 	// Implement the following methods:
 	// MoveFolder moves a folder to a new destination.
-	MoveFolder(name string, dst string) ([]Folder, error)
+	MoveFolder(name string, dst string, opts ...WriteOption) ([]Folder, error)
+	// MoveFolderInOrg moves a folder to a new destination, looking both up only
+	// within the given organisation. Returns the folders of that organisation.
+	MoveFolderInOrg(orgID uuid.UUID, name string, dst string, opts ...WriteOption) ([]Folder, error)
+
+	// Path based variants, folders are addressed by their full dot separated
+	// path so folders sharing a name in different branches can be told apart.
+	// GetAllChildFoldersByPath returns all child folders of the folder at path.
+	GetAllChildFoldersByPath(orgID uuid.UUID, path string) ([]Folder, error)
+	// GetChildFolders lists the folders below the folder at path, opts can
+	// limit the depth, include the folder itself and change the order.
+	GetChildFolders(orgID uuid.UUID, path string, opts ChildQueryOptions) ([]Folder, error)
+	// GetParent returns the parent folder of the folder at path.
+	GetParent(orgID uuid.UUID, path string) (Folder, error)
+	// GetAncestors returns every folder above the folder at path, ordered from
+	// the root down to its parent.
+	GetAncestors(orgID uuid.UUID, path string) ([]Folder, error)
+	// GetSiblings returns the other folders sharing a parent with the folder at path.
+	GetSiblings(orgID uuid.UUID, path string) ([]Folder, error)
+	// MoveFolderByPath moves the folder at src under the folder at dst.
+	// Returns the folders of that organisation.
+	MoveFolderByPath(orgID uuid.UUID, src string, dst string, opts ...WriteOption) ([]Folder, error)
+	// MoveFolderToRoot turns the folder at src into a root folder of its
+	// organisation, placed after the existing roots.
+	MoveFolderToRoot(orgID uuid.UUID, src string, opts ...WriteOption) ([]Folder, error)
+
+	// Positional moves, plain moves always place the folder after its new
+	// siblings. Each returns the folders of that organisation.
+	// MoveFolderToIndex moves the folder at src under the folder at dst, at
+	// index among its new siblings.
+	MoveFolderToIndex(orgID uuid.UUID, src string, dst string, index int, opts ...WriteOption) ([]Folder, error)
+	// MoveFolderBefore moves the folder at src right before the folder at sibling.
+	MoveFolderBefore(orgID uuid.UUID, src string, sibling string, opts ...WriteOption) ([]Folder, error)
+	// MoveFolderAfter moves the folder at src right after the folder at sibling.
+	MoveFolderAfter(orgID uuid.UUID, src string, sibling string, opts ...WriteOption) ([]Folder, error)
+
+	// ID based variants, a folder keeps its ID across moves and renames.
+	// GetFolderByID returns the folder with the given ID.
+	GetFolderByID(id uuid.UUID) (Folder, error)
+	// GetAllChildFoldersByID returns all child folders of the folder with the given ID.
+	GetAllChildFoldersByID(id uuid.UUID) ([]Folder, error)
+	// MoveFolderByID moves the folder with ID src under the folder with ID dst.
+	// Returns the folders of their organisation.
+	MoveFolderByID(src uuid.UUID, dst uuid.UUID, opts ...WriteOption) ([]Folder, error)
+
+	// CreateFolder creates a folder called name under the folder at parentPath,
+	// or a root folder when parentPath is empty.
+	CreateFolder(orgID uuid.UUID, parentPath string, name string) (Folder, error)
+	// DeleteFolder removes the folder at path, the policy decides what happens
+	// to its child folders.
+	DeleteFolder(orgID uuid.UUID, path string, policy DeletePolicy, opts ...WriteOption) (DeleteResult, error)
+	// RenameFolder renames the folder at path, rewriting the paths of its whole
+	// subtree. Returns the folders that changed.
+	RenameFolder(orgID uuid.UUID, path string, newName string, opts ...WriteOption) ([]Folder, error)
+	// CopyFolder copies the folder at src and its subtree under the folder at
+	// dst, or to the top level when dst is empty. Returns the new folders.
+	CopyFolder(orgID uuid.UUID, src string, dst string, opts CopyOptions) ([]Folder, error)
+	// TransferFolder hands the folder at src and its subtree over to another
+	// organisation, under the folder at dst there or to the top level when dst
+	// is empty. Unlike MoveFolder this crosses organisations on purpose.
+	TransferFolder(orgID uuid.UUID, src string, dstOrgID uuid.UUID, dst string, opts TransferOptions, writeOpts ...WriteOption) (TransferReport, error)
+
+	// ApplyBatch applies ops in order as one unit, each operation seeing the
+	// changes of the ones before it. If any fails nothing is changed.
+	ApplyBatch(ops []Operation) ([]OperationResult, error)
+	// Begin opens a transaction, see Tx.
+	Begin() Tx
+
+	// Every successful change is recorded in a history, see WithHistoryLimit.
+	// Undo reverts the latest change that hasn't been undone and returns it.
+	// The folders it puts back go up a version like any other change.
+	Undo() (HistoryEntry, error)
+	// Redo reapplies the latest undone change and returns it.
+	Redo() (HistoryEntry, error)
+	// History returns the recorded changes oldest first, undone ones last.
+	History() []HistoryEntry
+
+	// Snapshot returns a read-only view of the folders as they are now, later
+	// changes to the driver don't show through it.
+	Snapshot() *Snapshot
+
+	// Subscribe returns a feed of every change made from now on, buffering up
+	// to buffer events. See Subscription for what happens when it fills up.
+	Subscribe(buffer int) *Subscription
 }
 
 type driver struct {
-	// define attributes here
-	// data structure to store folders
-	// or preprocessed data
+	// readers share mu, every change holds it exclusively so no reader ever
+	// sees a change half applied
+	mu sync.RWMutex
+
+	// folders are grouped and indexed per organisation up front so queries
+	// never have to scan or sort the whole data set
+	orgs    map[uuid.UUID]*orgIndex
+	ids     ptree[uuid.UUID, uuid.UUID] // folder ID -> orgID
+	nextSeq int                         // insertion sequence handed to the next new folder
+	rev     int                         // bumped on every change, lets transactions spot other commits
+
+	history      []historyEntry
+	undone       int        // entries at the end of history that have been undone
+	historyLimit int        // entries kept, 0 keeps no history
+	pending      *changeSet // folders changed by the running call, nil on a working copy
+	origin       *changeSet // folders a working copy changed since it was taken, nil if not asked for
+	subs         []*Subscription
+	store        Store // makes changes durable, nil keeps them in memory only
+}
+
+// Option configures a driver created with NewDriver.
+type Option func(*driver)
+
+// NewDriver returns a driver over a copy of folders, the caller's slice is
+// never written to.
+func NewDriver(folders []Folder, opts ...Option) IDriver {
+	b := newDriverBuilder()
+	for _, folder := range folders {
+		b.add(folder)
+	}
+	return b.build(opts...)
+}
+
+/*
+driverBuilder groups folders by org as they arrive, so a driver can be built
+from a stream without first holding every folder in one slice. Folders get
+IDs the same way assignMissingIDs gives them.
+*/
+type driverBuilder struct {
+	byOrg map[uuid.UUID][]Folder
+	seqs  map[uuid.UUID][]int
+	ids   map[uuid.UUID]uuid.UUID
+	count int
+}
+
+func newDriverBuilder() *driverBuilder {
+	return &driverBuilder{
+		byOrg: make(map[uuid.UUID][]Folder),
+		seqs:  make(map[uuid.UUID][]int),
+		ids:   make(map[uuid.UUID]uuid.UUID),
+	}
+}
+
+func (b *driverBuilder) add(f Folder) {
+	if f.Id.IsNil() {
+		f.Id = DeriveFolderID(f.OrgId, f.Paths)
+	}
+	if _, seen := b.ids[f.Id]; seen {
+		f.Id = uuid.Must(uuid.NewV4())
+	}
+
+	b.byOrg[f.OrgId] = append(b.byOrg[f.OrgId], f)
+	b.seqs[f.OrgId] = append(b.seqs[f.OrgId], b.count)
+	b.ids[f.Id] = f.OrgId
+	b.count++
+}
+
+func (b *driverBuilder) build(opts ...Option) *driver {
+	orgs := make(map[uuid.UUID]*orgIndex, len(b.byOrg))
+	for orgID, orgFolders := range b.byOrg {
+		orgs[orgID] = buildOrgIndex(orgFolders, b.seqs[orgID])
+	}
+
+	f := &driver{
+		orgs:         orgs,
+		ids:          mapPtree(compareIDs, b.ids),
+		nextSeq:      b.count,
+		historyLimit: DefaultHistoryLimit,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	f.pending = newChangeSet()
+	return f
+}
+
+/*
+Returns a driver holding the same state that can be changed without touching f.
+Indexes are never modified once built, so only the map of orgs needs copying.
+The copy keeps no history and has no subscribers.
+*/
+func (f *driver) clone() *driver {
+	c := &driver{
+		orgs:    make(map[uuid.UUID]*orgIndex, len(f.orgs)),
+		ids:     f.ids,
+		nextSeq: f.nextSeq,
+		rev:     f.rev,
+	}
+	for orgID, idx := range f.orgs {
+		c.orgs[orgID] = idx
+	}
+	return c
+}
+
+/* Takes over the state of c, a clone of f keeping its origin that has since been changed */
+func (f *driver) adopt(c *driver) {
+	for _, set := range [...]*changeSet{f.pending, f.origin} {
+		if set != nil {
+			set.merge(c.origin)
+		}
+	}
+	f.orgs, f.ids, f.nextSeq, f.rev = c.orgs, c.ids, c.nextSeq, c.rev
+}
+
+/* Returns the index of an organisation, validating the orgID on the way */
+func (f *driver) orgIndex(orgID uuid.UUID) (*orgIndex, error) {
+	if orgID.IsNil() {
+		return nil, errors.New(ErrInvalidOrgID)
+	}
+
+	idx, exists := f.orgs[orgID]
+	if !exists {
+		return nil, errors.New(ErrFolderNotExistsOrg)
+	}
+	return idx, nil
+}
+
+/*
+Changes the folders of an organisation, each record in put is added or
+replaces the folder holding its sequence and the folders holding the
+sequences in removed are taken out. The org's index is patched rather than
+rebuilt and the org is dropped once it has no folders left. Versions are
+taken as given, see writeFolders.
+*/
+func (f *driver) setRecords(orgID uuid.UUID, put []folderRecord, removed []int) {
+	idx, exists := f.orgs[orgID]
+	if !exists {
+		idx = buildOrgIndex(nil, nil)
+	}
+
+	removedIDs := make([]uuid.UUID, 0, len(removed))
+	for _, seq := range removed {
+		if folder, held := idx.folders.Get(seq); held {
+			f.notePending(orgID, folder.Id, &folderRecord{folder, seq})
+			removedIDs = append(removedIDs, folder.Id)
+		}
+	}
+	for _, record := range put {
+		f.notePending(orgID, record.folder.Id, f.currentRecord(record.folder.Id))
+	}
+	f.rev++
+
+	idx = idx.patch(put, removed)
+	// a folder moving between orgs may already have been put in the other one
+	for _, id := range removedIDs {
+		if owner, _ := f.ids.Get(id); owner == orgID {
+			f.ids = f.ids.Delete(id)
+		}
+	}
+	for _, record := range put {
+		f.ids = f.ids.Put(record.folder.Id, orgID)
+	}
 
-	// example: feel free to change the data structure, if slice is not what you want
-	folders []Folder
+	if idx.len() == 0 {
+		delete(f.orgs, orgID)
+		return
+	}
+	f.orgs[orgID] = idx
+}
+
+/* Writes folders back to their org like setRecords, those that changed go up a version */
+func (f *driver) writeFolders(orgID uuid.UUID, put []folderRecord, removed []int) {
+	if old, exists := f.orgs[orgID]; exists {
+		bumpVersions(old, put)
+	}
+	f.setRecords(orgID, put, removed)
 }
 
-func NewDriver(folders []Folder) IDriver {
-	return &driver{
-		// initialize attributes here
-		folders: folders,
+/* Returns a folder as its org holds it now, nil if no org does */
+func (f *driver) currentRecord(id uuid.UUID) *folderRecord {
+	orgID, live := f.ids.Get(id)
+	if !live {
+		return nil
+	}
+	idx := f.orgs[orgID]
+	seq, _ := idx.byID.Get(id)
+	return &folderRecord{idx.folder(seq), seq}
+}
+
+/* Returns the folder with the given ID along with the index of its organisation */
+func (f *driver) folderByID(id uuid.UUID, errNotExist string) (Folder, *orgIndex, error) {
+	orgID, exists := f.ids.Get(id)
+	if !exists {
+		return Folder{}, nil, errors.New(errNotExist)
+	}
+
+	idx := f.orgs[orgID]
+	seq, _ := idx.byID.Get(id)
+	if idx.errs[seq] != nil {
+		return Folder{}, nil, idx.errs[seq]
+	}
+	return idx.folder(seq), idx, nil
+}
+
+/* Returns every folder across all organisations in the order they were added */
+func (f *driver) allFolders() []Folder {
+	type entry struct {
+		seq    int
+		folder Folder
+	}
+
+	entries := []entry{}
+	for _, idx := range f.orgs {
+		idx.folders.Ascend(func(seq int, folder Folder) bool {
+			entries = append(entries, entry{seq, folder})
+			return true
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	res := make([]Folder, len(entries))
+	for i, e := range entries {
+		res[i] = e.folder
 	}
+	return res
 }
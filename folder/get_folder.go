@@ -3,7 +3,6 @@ package folder
 import (
 	//"fmt"
 	"errors"
-	"sort"
 	"strings"
 	"github.com/gofrs/uuid"
 	"unicode" // for path checking
@@ -14,16 +13,19 @@ func GetAllFolders() []Folder {
 }
 
 func (f *driver) GetFoldersByOrgID(orgID uuid.UUID) []Folder {
-	folders := f.folders
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 
-	res := []Folder{}
-	for _, f := range folders {
-		if f.OrgId == orgID {
-			res = append(res, f)
-		}
+	return f.orgFolders(orgID)
+}
+
+func (f *driver) orgFolders(orgID uuid.UUID) []Folder {
+	idx, exists := f.orgs[orgID]
+	if !exists {
+		return []Folder{}
 	}
 
-	return res
+	return idx.list()
 }
 
 func ValidateFilePath(path string) bool {
@@ -59,7 +61,7 @@ func ValidateFolderEndOfPath(folder Folder) bool {
 /* Validates previous folders have previously been seen */
 func ValidateChildPathStructure(path string, seen map[string]int) error {
 	splitPaths := strings.Split(path, ".") // Expects current folder to be a child to a previous folder
-	if len(splitPaths) < 2 {
+	if len(splitPaths) < 2 || splitPaths[len(splitPaths) - 1] == "" {
 		return errors.New(ErrInvalidFilePathStructure + " " + path)
 	}
 	/* all previous files must be seen in order for the current path to be valid because we have 
@@ -70,69 +72,184 @@ func ValidateChildPathStructure(path string, seen map[string]int) error {
 	return errors.New(ErrUnseenFolder + " " + path + " for " + splitPaths[len(splitPaths) - 2]) 
 }
 
-/* 
-The main premise of the algorithm is to sort the list of folders by their path name.
-By lexicographically sorting the paths, we can ensure that they are ordered correctly by their
-hierarchy. 
+/*
+The folders of each organisation are indexed once, when the driver is built.
+Each folder's children are kept in sibling order, so the subtree is collected
+by walking down from the root folder and visiting the result only.
 */
 func (f *driver) GetAllChildFolders(orgID uuid.UUID, name string) ([]Folder, error) {
-	if orgID.IsNil() {
-		return nil, errors.New(ErrInvalidOrgID)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := idx.nameSeqs(name)
+	if len(matches) == 0 {
+		if idx.invalid {
+			return nil, errors.New(ErrInvalidFilePath)
+		}
+		return nil, errors.New(ErrFolderNotExist)
 	}
 
-	sameOriginFolders := f.GetFoldersByOrgID(orgID)
+	// first match in path order is the root folder, as before
+	return idx.descendants(idx.folder(matches[0]).Paths)
+}
+
+func (f *driver) GetAllChildFoldersByPath(orgID uuid.UUID, path string) ([]Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 
-	if len(sameOriginFolders) == 0 {
-		return nil, errors.New(ErrFolderNotExistsOrg)
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return nil, err
 	}
 
+	root, err := idx.resolvePath(path, ErrFolderNotExist)
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.descendants(root.Paths)
+}
 
-	/* sorting the folders of same origin to ensure child folders are in correct 
-	ordering, also allow for prev folder checking. */ 
-	sort.SliceStable(sameOriginFolders, func(i, j int) bool {
-		return sameOriginFolders[i].Paths < sameOriginFolders[j].Paths
-	})
+func (f *driver) GetFolderByID(id uuid.UUID) (Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 
+	folder, _, err := f.folderByID(id, ErrFolderNotExist)
+	return folder, err
+}
 
-	var rootFolder *Folder
-	seen := make(map[string]int)
+func (f *driver) GetAllChildFoldersByID(id uuid.UUID) ([]Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	root, idx, err := f.folderByID(id, ErrFolderNotExist)
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.descendants(root.Paths)
+}
+
+// ChildOrder picks the order GetChildFolders lists folders in.
+type ChildOrder int
+
+const (
+	// OrderPath lists folders depth first, each folder followed by its subtree.
+	OrderPath ChildOrder = iota
+	// OrderBreadthFirst lists folders level by level.
+	OrderBreadthFirst
+)
+
+// ChildQueryOptions tunes GetChildFolders, the zero value behaves like
+// GetAllChildFoldersByPath.
+type ChildQueryOptions struct {
+	MaxDepth    int  // levels below the folder to return, 1 for direct children only, 0 for no limit
+	IncludeRoot bool // list the folder itself first
+	Order       ChildOrder
+}
+
+func (f *driver) GetChildFolders(orgID uuid.UUID, path string, opts ChildQueryOptions) ([]Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if opts.MaxDepth < 0 {
+		return nil, errors.New(ErrInvalidMaxDepth)
+	}
+	if opts.Order < OrderPath || opts.Order > OrderBreadthFirst {
+		return nil, errors.New(ErrUnknownChildOrder)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return nil, err
+	}
+	root, err := idx.resolvePath(path, ErrFolderNotExist)
+	if err != nil {
+		return nil, err
+	}
 
 	res := []Folder{}
-	for i := range sameOriginFolders {
-		f := &sameOriginFolders[i]
+	if opts.IncludeRoot {
+		res = append(res, root)
+	}
 
-		if !ValidateFilePath(f.Paths) {
-			return nil, errors.New(ErrInvalidFilePath)
-		}
+	children, err := idx.walk(root.Paths, opts.MaxDepth, opts.Order)
+	if err != nil {
+		return nil, err
+	}
 
-		// finding root folder
-		if rootFolder == nil && f.Name == name {
-			rootFolder = f
-			seen[f.Name] = 1
-			continue // continue as root folder is not child folder
-		}
+	return append(res, children...), nil
+}
+
+func (f *driver) GetParent(orgID uuid.UUID, path string) (Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	ancestors, err := f.ancestors(orgID, path)
+	if err != nil {
+		return Folder{}, err
+	}
+	if len(ancestors) == 0 {
+		return Folder{}, errors.New(ErrFolderIsRoot)
+	}
+
+	return ancestors[len(ancestors) - 1], nil
+}
+
+func (f *driver) GetAncestors(orgID uuid.UUID, path string) ([]Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.ancestors(orgID, path)
+}
 
-		if rootFolder != nil { 
-			if len(f.Paths) > len(rootFolder.Paths) && f.Paths[:len(rootFolder.Paths)] == rootFolder.Paths {
-				if !ValidateFolderEndOfPath(*f) {
-					return nil, errors.New(ErrFolderNotMatchPathEnd + " " + f.Paths)
-				}
-
-				err := ValidateChildPathStructure(f.Paths, seen)
-				if err != nil {
-					return nil, err
-				}
-
-				res = append(res, *f)
-				seen[f.Name] = 1
-			} else {
-				break //early exit since list is sorted, no other child will exist if previous conditions fail
-			}
+/* Every label of the path names an ancestor, each must have a folder record */
+func (f *driver) ancestors(orgID uuid.UUID, path string) ([]Folder, error) {
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := idx.resolvePath(path, ErrFolderNotExist); err != nil {
+		return nil, err
+	}
+
+	res := []Folder{}
+	for i := range path {
+		if path[i] != '.' {
+			continue
 		}
+		seq, exists := idx.byPath.Get(path[:i])
+		if !exists {
+			return nil, errors.New(ErrUnseenFolder + " " + path + " for " + lastLabel(path[:i]))
+		}
+		res = append(res, idx.folder(seq))
 	}
 
-	if rootFolder == nil {
-		return nil, errors.New(ErrFolderNotExist)
+	return res, nil
+}
+
+func (f *driver) GetSiblings(orgID uuid.UUID, path string) ([]Folder, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := idx.resolvePath(path, ErrFolderNotExist); err != nil {
+		return nil, err
+	}
+
+	res := []Folder{}
+	for _, seq := range idx.childSeqs(parentPath(path)) {
+		if sibling := idx.folder(seq); sibling.Paths != path {
+			res = append(res, sibling)
+		}
 	}
 
 	return res, nil
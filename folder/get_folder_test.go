@@ -8,6 +8,22 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+/* Fills in the IDs NewDriver derives for folders given without one */
+func withIDs(folders []folder.Folder) []folder.Folder {
+	if folders == nil {
+		return nil
+	}
+
+	res := make([]folder.Folder, len(folders))
+	for i, f := range folders {
+		if f.Id.IsNil() {
+			f.Id = folder.DeriveFolderID(f.OrgId, f.Paths)
+		}
+		res[i] = f
+	}
+	return res
+}
+
 func Test_folder_GetFoldersByOrgID(t *testing.T) {
 	t.Parallel()
 	tests := [...]struct {
@@ -318,7 +334,383 @@ func Test_folder_GetAllChildFolders(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+}
+func Test_folder_GetAllChildFoldersByPath(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "reports", OrgId: orgID, Paths: "A.reports"},
+		{Name: "C", OrgId: orgID, Paths: "A.reports.C"},
+		{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+		{Name: "reports", OrgId: orgID, Paths: "B.reports"},
+		{Name: "D", OrgId: orgID, Paths: "B.reports.D"},
+	}
+	tests := [...]struct {
+		name string
+		orgID uuid.UUID
+		path string
+		folders []folder.Folder
+		wantFolders []folder.Folder
+		wantErr error
+	} {
+		{
+			name: "Duplicate name in first branch",
+			orgID: orgID,
+			path: "A.reports",
+			folders: folders,
+			wantFolders: []folder.Folder {
+				{Name: "C", OrgId: orgID, Paths: "A.reports.C"},
+			},
+		},
+		{
+			name: "Duplicate name in second branch",
+			orgID: orgID,
+			path: "B.reports",
+			folders: folders,
+			wantFolders: []folder.Folder {
+				{Name: "D", OrgId: orgID, Paths: "B.reports.D"},
+			},
+		},
+		{
+			name: "Root path",
+			orgID: orgID,
+			path: "B",
+			folders: folders,
+			wantFolders: []folder.Folder {
+				{Name: "reports", OrgId: orgID, Paths: "B.reports"},
+				{Name: "D", OrgId: orgID, Paths: "B.reports.D"},
+			},
+		},
+		{
+			name: "Bare name is not a path",
+			orgID: orgID,
+			path: "reports",
+			folders: folders,
+			wantErr: errors.New(folder.ErrFolderNotExist),
+		},
+		{
+			name: "Invalid path",
+			orgID: orgID,
+			path: "A..reports",
+			folders: folders,
+			wantErr: errors.New(folder.ErrInvalidFilePath),
+		},
+		{
+			name: "Path held by two folders",
+			orgID: orgID,
+			path: "A",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "A", OrgId: orgID, Paths: "A"},
+			},
+			wantErr: errors.New(folder.ErrDuplicatePath + " A"),
+		},
+		{
+			name: "Invalid nil orgID",
+			orgID: uuid.Nil,
+			path: "A",
+			folders: folders,
+			wantErr: errors.New(folder.ErrInvalidOrgID),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(tt.folders)
+			get, err := f.GetAllChildFoldersByPath(tt.orgID, tt.path)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+}
+
+func Test_folder_GetSampleDataIDs(t *testing.T) {
+	t.Parallel()
+	first := folder.GetSampleData()
+	second := folder.GetSampleData()
+
+	// older files have no IDs, they are derived so every load agrees
+	assert.Equal(t, first, second)
+	seen := map[uuid.UUID]bool{}
+	for _, f := range first {
+		assert.False(t, f.Id.IsNil())
+		assert.False(t, seen[f.Id])
+		seen[f.Id] = true
+	}
+}
+
+func Test_folder_GetFolderByID(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	givenID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder {
+		{Id: givenID, Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+	}
+	f := folder.NewDriver(folders)
+
+	get, err := f.GetFolderByID(givenID)
+	assert.NoError(t, err)
+	assert.Equal(t, folders[0], get)
+
+	children, err := f.GetAllChildFoldersByID(givenID)
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs(folders[1:]), children)
+
+	children, err = f.GetAllChildFoldersByID(folder.DeriveFolderID(orgID, "A.B.C"))
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{}, children)
+
+	_, err = f.GetFolderByID(uuid.Must(uuid.NewV4()))
+	assert.EqualError(t, err, folder.ErrFolderNotExist)
+
+	children, err = f.GetAllChildFoldersByID(uuid.Nil)
+	assert.EqualError(t, err, folder.ErrFolderNotExist)
+	assert.Nil(t, children)
+}
+
+func Test_folder_GetAncestors(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "A.B.D", Position: 1},
+		{Name: "E", OrgId: orgID, Paths: "A.E", Position: 1},
+		{Name: "F", OrgId: orgID, Paths: "F", Position: 1},
+		{Name: "H", OrgId: orgID, Paths: "G.H"}, // orphaned, G has no record
+		{Name: "I", OrgId: orgID, Paths: "G.H.I"},
+	}
+	tests := [...]struct {
+		name string
+		path string
+		wantAncestors []folder.Folder
+		wantParent folder.Folder
+		wantSiblings []folder.Folder
+		wantErr error
+		wantParentErr error
+	} {
+		{
+			name: "Leaf folder",
+			path: "A.B.C",
+			wantAncestors: withIDs([]folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+			}),
+			wantParent: withIDs(folders[1:2])[0],
+			wantSiblings: withIDs([]folder.Folder {
+				{Name: "D", OrgId: orgID, Paths: "A.B.D", Position: 1},
+			}),
+		},
+		{
+			name: "Root folder",
+			path: "A",
+			wantAncestors: []folder.Folder{},
+			wantSiblings: withIDs([]folder.Folder {
+				{Name: "F", OrgId: orgID, Paths: "F", Position: 1},
+			}),
+			wantParentErr: errors.New(folder.ErrFolderIsRoot),
+		},
+		{
+			name: "Only child",
+			path: "A.E",
+			wantAncestors: withIDs(folders[:1]),
+			wantParent: withIDs(folders[:1])[0],
+			wantSiblings: withIDs([]folder.Folder {
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+			}),
+		},
+		{
+			name: "Parent without a record",
+			path: "G.H",
+			wantErr: errors.New(folder.ErrUnseenFolder + " G.H for G"),
+		},
+		{
+			name: "Ancestor without a record",
+			path: "G.H.I",
+			wantErr: errors.New(folder.ErrUnseenFolder + " G.H.I for G"),
+		},
+		{
+			name: "Non-existent folder",
+			path: "A.X",
+			wantErr: errors.New(folder.ErrFolderNotExist),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+
+			ancestors, err := f.GetAncestors(orgID, tt.path)
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				_, err = f.GetParent(orgID, tt.path)
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAncestors, ancestors)
+
+			parent, err := f.GetParent(orgID, tt.path)
+			if tt.wantParentErr != nil {
+				assert.EqualError(t, err, tt.wantParentErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantParent, parent)
+
+			siblings, err := f.GetSiblings(orgID, tt.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSiblings, siblings)
+		})
+	}
+
+	f := folder.NewDriver(folders)
+	siblings, err := f.GetSiblings(orgID, "G.H")
+	assert.EqualError(t, err, folder.ErrUnseenFolder + " G.H for G")
+	assert.Nil(t, siblings)
+}
+
+func Test_folder_GetChildFolders(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "A.B.C.D"},
+		{Name: "E", OrgId: orgID, Paths: "A.E", Position: 1},
+		{Name: "F", OrgId: orgID, Paths: "A.E.F"},
+	})
+	a, b, c, d, e, ff := folders[0], folders[1], folders[2], folders[3], folders[4], folders[5]
+	tests := [...]struct {
+		name string
+		path string
+		opts folder.ChildQueryOptions
+		wantFolders []folder.Folder
+		wantErr error
+	} {
+		{
+			name: "Default is the whole subtree in path order",
+			path: "A",
+			opts: folder.ChildQueryOptions{},
+			wantFolders: []folder.Folder{b, c, d, e, ff},
+		},
+		{
+			name: "Direct children only",
+			path: "A",
+			opts: folder.ChildQueryOptions{MaxDepth: 1},
+			wantFolders: []folder.Folder{b, e},
+		},
+		{
+			name: "Two levels in path order",
+			path: "A",
+			opts: folder.ChildQueryOptions{MaxDepth: 2},
+			wantFolders: []folder.Folder{b, c, e, ff},
+		},
+		{
+			name: "Two levels breadth first",
+			path: "A",
+			opts: folder.ChildQueryOptions{MaxDepth: 2, Order: folder.OrderBreadthFirst},
+			wantFolders: []folder.Folder{b, e, c, ff},
+		},
+		{
+			name: "Whole subtree breadth first with root",
+			path: "A",
+			opts: folder.ChildQueryOptions{IncludeRoot: true, Order: folder.OrderBreadthFirst},
+			wantFolders: []folder.Folder{a, b, e, c, ff, d},
+		},
+		{
+			name: "Include root of a leaf",
+			path: "A.B.C.D",
+			opts: folder.ChildQueryOptions{IncludeRoot: true, MaxDepth: 1},
+			wantFolders: []folder.Folder{d},
+		},
+		{
+			name: "Depth beyond the tree",
+			path: "A.B",
+			opts: folder.ChildQueryOptions{MaxDepth: 10},
+			wantFolders: []folder.Folder{c, d},
+		},
+		{
+			name: "Negative depth",
+			path: "A",
+			opts: folder.ChildQueryOptions{MaxDepth: -1},
+			wantErr: errors.New(folder.ErrInvalidMaxDepth),
+		},
+		{
+			name: "Unknown order",
+			path: "A",
+			opts: folder.ChildQueryOptions{Order: folder.ChildOrder(42)},
+			wantErr: errors.New(folder.ErrUnknownChildOrder),
+		},
+		{
+			name: "Non-existent folder",
+			path: "B",
+			opts: folder.ChildQueryOptions{MaxDepth: 1},
+			wantErr: errors.New(folder.ErrFolderNotExist),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.GetChildFolders(orgID, tt.path, tt.opts)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
 			assert.Equal(t, tt.wantFolders, get)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func Test_folder_ChildrenInSiblingOrder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "C", OrgId: orgID, Paths: "A.C", Position: 5},
+		{Name: "D", OrgId: orgID, Paths: "A.C.D"},
+		{Name: "B", OrgId: orgID, Paths: "A.B", Position: 2},
+		{Name: "E", OrgId: orgID, Paths: "A.E"}, // no position, ties keep insertion order
+		{Name: "F", OrgId: orgID, Paths: "A.F"},
+	}
+	f := folder.NewDriver(folders)
+
+	children, err := f.GetAllChildFolders(orgID, "A")
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs([]folder.Folder {
+		{Name: "E", OrgId: orgID, Paths: "A.E"},
+		{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+		{Name: "B", OrgId: orgID, Paths: "A.B", Position: 2},
+		{Name: "C", OrgId: orgID, Paths: "A.C", Position: 5},
+		{Name: "D", OrgId: orgID, Paths: "A.C.D"},
+	}), children)
+
+	siblings, err := f.GetSiblings(orgID, "A.B")
+	assert.NoError(t, err)
+	assert.Equal(t, withIDs([]folder.Folder {
+		{Name: "E", OrgId: orgID, Paths: "A.E"},
+		{Name: "F", OrgId: orgID, Paths: "A.F", Position: 1},
+		{Name: "C", OrgId: orgID, Paths: "A.C", Position: 5},
+	}), siblings)
+
+	breadthFirst, err := f.GetChildFolders(orgID, "A", folder.ChildQueryOptions{Order: folder.OrderBreadthFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A.E", "A.F", "A.B", "A.C", "A.C.D"}, []string{
+		breadthFirst[0].Paths, breadthFirst[1].Paths, breadthFirst[2].Paths, breadthFirst[3].Paths, breadthFirst[4].Paths,
+	})
+}
@@ -0,0 +1,315 @@
+package folder
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/gofrs/uuid"
+)
+
+// DefaultHistoryLimit is how many changes a driver remembers unless told otherwise.
+const DefaultHistoryLimit = 100
+
+// WithHistoryLimit sets how many changes are kept for Undo, the oldest are
+// dropped first. A limit of 0 turns the history off. Each change keeps a copy
+// of the folders it touched, from before and after it.
+func WithHistoryLimit(limit int) Option {
+	return func(f *driver) {
+		if limit < 0 {
+			limit = 0
+		}
+		f.historyLimit = limit
+	}
+}
+
+// HistoryEntry describes a recorded change.
+type HistoryEntry struct {
+	Op     string      // driver method that made the change, e.g. "MoveFolder"
+	OrgIDs []uuid.UUID // organisations the change touched
+	Undone bool        // the change has been undone and can be redone
+}
+
+/*
+A change is stored as the folders it touched, each as it was before and after
+it, so an entry costs as much as the change rather than the orgs it touched.
+Records keep the folder's insertion sequence and Position, so putting them
+back restores the exact state, sibling positions included. Only versions
+differ, an Undo or Redo is a change like any other, see reversion.
+*/
+type historyEntry struct {
+	op      string
+	orgIDs  []uuid.UUID // organisations the change touched, sorted
+	changes map[uuid.UUID]folderChange
+}
+
+/* A single folder touched by a change, a nil side is an org not holding it */
+type folderChange struct {
+	before *folderRecord
+	after  *folderRecord
+}
+
+/* A folder as its org holds it */
+type folderRecord struct {
+	folder Folder
+	seq    int
+}
+
+/* The folders changed since some point, as they were then, and the orgs they were changed in */
+type changeSet struct {
+	orgs    map[uuid.UUID]bool
+	folders map[uuid.UUID]*folderRecord // nil for a folder that didn't exist
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{
+		orgs:    make(map[uuid.UUID]bool),
+		folders: make(map[uuid.UUID]*folderRecord),
+	}
+}
+
+/* Notes how a folder looked before it was changed in an org, the first note for a folder wins */
+func (c *changeSet) note(orgID uuid.UUID, id uuid.UUID, before *folderRecord) {
+	c.orgs[orgID] = true
+	if _, noted := c.folders[id]; !noted {
+		c.folders[id] = before
+	}
+}
+
+/* Adds the notes of a later change set */
+func (c *changeSet) merge(later *changeSet) {
+	for orgID := range later.orgs {
+		c.orgs[orgID] = true
+	}
+	for id, before := range later.folders {
+		if _, noted := c.folders[id]; !noted {
+			c.folders[id] = before
+		}
+	}
+}
+
+/* Remembers how a folder looked before the running call first changed it, and before a working copy did */
+func (f *driver) notePending(orgID uuid.UUID, id uuid.UUID, before *folderRecord) {
+	for _, set := range [...]*changeSet{f.pending, f.origin} {
+		if set != nil {
+			set.note(orgID, id, before)
+		}
+	}
+}
+
+/* Hands over what the running call changed, the folders as they were and are now, and starts afresh */
+func (f *driver) takePending() ([]uuid.UUID, map[uuid.UUID]folderChange) {
+	if f.pending == nil || len(f.pending.orgs) == 0 {
+		return nil, nil
+	}
+	pending := f.pending
+	f.pending = newChangeSet()
+
+	changes := make(map[uuid.UUID]folderChange, len(pending.folders))
+	for id, before := range pending.folders {
+		after := f.currentRecord(id)
+		if before == nil && after == nil || before != nil && after != nil && *before == *after {
+			continue
+		}
+		changes[id] = folderChange{before: before, after: after}
+	}
+	return sortedOrgIDs(pending.orgs), changes
+}
+
+/*
+Turns the changes noted by the running call into a history entry, saves them
+and tells subscribers about them. Mutating methods defer it with their error
+result, so a call that failed before changing anything records nothing and a
+change the store refuses is undone and reported through err. A change the
+store kept in spite of an error is recorded and reported.
+*/
+func (f *driver) record(op string, err *error) {
+	orgIDs, changes := f.takePending()
+	if orgIDs == nil {
+		return
+	}
+
+	if commitErr := f.commit(op, changes); commitErr != nil {
+		*err = commitErr
+		if !savedAnyway(commitErr) {
+			return
+		}
+	}
+	if f.historyLimit == 0 {
+		return
+	}
+
+	entry := historyEntry{op: op, orgIDs: orgIDs, changes: changes}
+
+	// a new change replaces whatever was undone
+	f.history = append(f.history[:len(f.history)-f.undone], entry)
+	f.undone = 0
+	if len(f.history) > f.historyLimit {
+		f.history = f.history[len(f.history)-f.historyLimit:]
+	}
+}
+
+/*
+Saves a change that has already been applied and passes it on to subscribers.
+If the store fails the folders are put back as they were before, unless it
+failed after the change was saved.
+*/
+func (f *driver) commit(op string, changes map[uuid.UUID]folderChange) error {
+	if f.store == nil && len(f.subs) == 0 {
+		return nil
+	}
+
+	event := ChangeEvent{Op: op, Changes: sortedChanges(changes)}
+	if len(event.Changes) == 0 {
+		return nil
+	}
+	var err error
+	if f.store != nil {
+		err = f.store.Save(event, f.snapshot())
+		if err != nil && !savedAnyway(err) {
+			f.revert(changes)
+			return err
+		}
+	}
+	f.publish(event)
+	return err
+}
+
+/* Reports whether a store error came after the change was saved */
+func savedAnyway(err error) bool {
+	var compactErr *CompactError
+	return errors.As(err, &compactErr)
+}
+
+func (f *driver) Undo() (HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.undone == len(f.history) {
+		return HistoryEntry{}, errors.New(ErrNothingToUndo)
+	}
+
+	entry := &f.history[len(f.history)-f.undone-1]
+	err := f.restoreEntry("Undo", entry, true)
+	if err != nil && !savedAnyway(err) {
+		return HistoryEntry{}, err
+	}
+	f.undone++
+	return entry.describe(true), err
+}
+
+func (f *driver) Redo() (HistoryEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.undone == 0 {
+		return HistoryEntry{}, errors.New(ErrNothingToRedo)
+	}
+
+	entry := &f.history[len(f.history)-f.undone]
+	err := f.restoreEntry("Redo", entry, false)
+	if err != nil && !savedAnyway(err) {
+		return HistoryEntry{}, err
+	}
+	f.undone--
+	return entry.describe(false), err
+}
+
+func (f *driver) History() []HistoryEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	res := make([]HistoryEntry, len(f.history))
+	for i, entry := range f.history {
+		res[i] = entry.describe(i >= len(f.history)-f.undone)
+	}
+	return res
+}
+
+/*
+Puts the folders of a history entry back as they were before the change, or
+after it when redoing, with versions moved on. The entry is updated to what
+was restored, so it carries the versions a later Undo or Redo has to stay
+above.
+*/
+func (f *driver) restoreEntry(op string, entry *historyEntry, undo bool) error {
+	records := f.reversion(*entry, undo)
+	f.putRecords(records)
+	_, changes := f.takePending()
+	err := f.commit(op, changes)
+	if err != nil && !savedAnyway(err) {
+		return err
+	}
+	for id, record := range records {
+		if record == nil {
+			continue
+		}
+		change := entry.changes[id]
+		if undo {
+			change.before = record
+		} else {
+			change.after = record
+		}
+		entry.changes[id] = change
+	}
+	return err
+}
+
+/* Puts the folders of a failed change back exactly as they were, versions included */
+func (f *driver) revert(changes map[uuid.UUID]folderChange) {
+	records := make(map[uuid.UUID]*folderRecord, len(changes))
+	for id, change := range changes {
+		records[id] = change.before
+	}
+	f.putRecords(records)
+	if f.pending != nil {
+		f.pending = newChangeSet()
+	}
+}
+
+/*
+Makes the driver hold each folder as its record says, taking it out of the
+org it is in now, or out of every org for a nil record.
+*/
+func (f *driver) putRecords(records map[uuid.UUID]*folderRecord) {
+	type orgChange struct {
+		put     []folderRecord
+		removed []int
+	}
+
+	byOrg := make(map[uuid.UUID]*orgChange)
+	change := func(orgID uuid.UUID) *orgChange {
+		if byOrg[orgID] == nil {
+			byOrg[orgID] = &orgChange{}
+		}
+		return byOrg[orgID]
+	}
+	for id, record := range records {
+		if current := f.currentRecord(id); current != nil {
+			c := change(current.folder.OrgId)
+			c.removed = append(c.removed, current.seq)
+		}
+		if record != nil {
+			c := change(record.folder.OrgId)
+			c.put = append(c.put, *record)
+		}
+	}
+	for orgID, c := range byOrg {
+		f.setRecords(orgID, c.put, c.removed)
+	}
+}
+
+func (e historyEntry) describe(undone bool) HistoryEntry {
+	return HistoryEntry{Op: e.op, OrgIDs: append([]uuid.UUID{}, e.orgIDs...), Undone: undone}
+}
+
+func sortedOrgIDs(orgs map[uuid.UUID]bool) []uuid.UUID {
+	orgIDs := make([]uuid.UUID, 0, len(orgs))
+	for orgID := range orgs {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool {
+		return bytes.Compare(orgIDs[i][:], orgIDs[j][:]) < 0
+	})
+	return orgIDs
+}
@@ -0,0 +1,233 @@
+package folder_test
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_UndoRedo(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.C", Position: 1},
+		{Name: "D", OrgId: orgID, Paths: "A.D", Position: 2},
+		{Name: "E", OrgId: orgID, Paths: "E", Position: 1},
+		{Name: "X", OrgId: otherOrgID, Paths: "X"},
+	})
+	tests := [...]struct {
+		name string
+		change func(t *testing.T, f folder.IDriver)
+		wantEntry folder.HistoryEntry
+	} {
+		{
+			name: "Move keeps sibling order",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.MoveFolderBefore(orgID, "A.D", "A.B")
+				assert.NoError(t, err)
+			},
+			wantEntry: folder.HistoryEntry{Op: "MoveFolderBefore", OrgIDs: []uuid.UUID{orgID}},
+		},
+		{
+			name: "Delete with reparenting",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.DeleteFolder(orgID, "A", folder.DeleteReparent)
+				assert.NoError(t, err)
+			},
+			wantEntry: folder.HistoryEntry{Op: "DeleteFolder", OrgIDs: []uuid.UUID{orgID}},
+		},
+		{
+			name: "Transfer across orgs",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.TransferFolder(orgID, "A", otherOrgID, "X", folder.TransferOptions{})
+				assert.NoError(t, err)
+			},
+			wantEntry: folder.HistoryEntry{Op: "TransferFolder", OrgIDs: sortedIDs(orgID, otherOrgID)},
+		},
+		{
+			name: "Batch is a single change",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.ApplyBatch([]folder.Operation {
+					{Kind: folder.OpCreate, OrgID: orgID, Path: "E", Name: "F"},
+					{Kind: folder.OpMove, OrgID: orgID, Path: "A.C", Dst: "E.F"},
+				})
+				assert.NoError(t, err)
+			},
+			wantEntry: folder.HistoryEntry{Op: "ApplyBatch", OrgIDs: []uuid.UUID{orgID}},
+		},
+		{
+			name: "Committed transaction is a single change",
+			change: func(t *testing.T, f folder.IDriver) {
+				tx := f.Begin()
+				_, err := tx.RenameFolder(orgID, "A", "Z")
+				assert.NoError(t, err)
+				_, err = tx.CreateFolder(otherOrgID, "X", "Y")
+				assert.NoError(t, err)
+				assert.NoError(t, tx.Commit())
+			},
+			wantEntry: folder.HistoryEntry{Op: "Commit", OrgIDs: sortedIDs(orgID, otherOrgID)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+			tt.change(t, f)
+			changed := snapshot(f, orgID, otherOrgID)
+
+			entry, err := f.Undo()
+			assert.NoError(t, err)
+			tt.wantEntry.Undone = true
+			assert.Equal(t, tt.wantEntry, entry)
+			undone := snapshot(f, orgID, otherOrgID)
+			assert.Equal(t, withoutVersions(folders[:5], folders[5:]), withoutVersions(undone...))
+			for _, folder := range folders {
+				got, err := f.GetFolderByID(folder.Id)
+				assert.NoError(t, err)
+				assert.Equal(t, folder.Paths, got.Paths)
+			}
+			assertVersionsUp(t, changed, undone)
+
+			assert.Equal(t, []folder.HistoryEntry{tt.wantEntry}, f.History())
+
+			entry, err = f.Redo()
+			assert.NoError(t, err)
+			tt.wantEntry.Undone = false
+			assert.Equal(t, tt.wantEntry, entry)
+			redone := snapshot(f, orgID, otherOrgID)
+			assert.Equal(t, withoutVersions(changed...), withoutVersions(redone...))
+			assertVersionsUp(t, undone, redone)
+		})
+	}
+}
+
+func Test_folder_History(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+	}
+	create := func(t *testing.T, f folder.IDriver, name string) {
+		_, err := f.CreateFolder(orgID, "A", name)
+		assert.NoError(t, err)
+	}
+	entry := func(op string, undone bool) folder.HistoryEntry {
+		return folder.HistoryEntry{Op: op, OrgIDs: []uuid.UUID{orgID}, Undone: undone}
+	}
+
+	t.Run("Failed changes are not recorded", func(t *testing.T) {
+		t.Parallel()
+		f := folder.NewDriver(folders)
+		_, err := f.MoveFolder("A", "A")
+		assert.Error(t, err)
+		assert.Equal(t, []folder.HistoryEntry{}, f.History())
+		_, err = f.Undo()
+		assert.Equal(t, errors.New(folder.ErrNothingToUndo), err)
+		_, err = f.Redo()
+		assert.Equal(t, errors.New(folder.ErrNothingToRedo), err)
+	})
+
+	t.Run("A new change drops undone ones", func(t *testing.T) {
+		t.Parallel()
+		f := folder.NewDriver(folders)
+		create(t, f, "B")
+		create(t, f, "C")
+		_, err := f.Undo()
+		assert.NoError(t, err)
+		assert.Equal(t, []folder.HistoryEntry{entry("CreateFolder", false), entry("CreateFolder", true)}, f.History())
+
+		_, err = f.RenameFolder(orgID, "A.B", "D")
+		assert.NoError(t, err)
+		assert.Equal(t, []folder.HistoryEntry{entry("CreateFolder", false), entry("RenameFolder", false)}, f.History())
+		_, err = f.Redo()
+		assert.Equal(t, errors.New(folder.ErrNothingToRedo), err)
+	})
+
+	t.Run("Limit drops the oldest changes", func(t *testing.T) {
+		t.Parallel()
+		f := folder.NewDriver(folders, folder.WithHistoryLimit(2))
+		create(t, f, "B")
+		create(t, f, "C")
+		create(t, f, "D")
+		assert.Len(t, f.History(), 2)
+
+		for range 2 {
+			_, err := f.Undo()
+			assert.NoError(t, err)
+		}
+		_, err := f.Undo()
+		assert.Equal(t, errors.New(folder.ErrNothingToUndo), err)
+		assert.Len(t, f.GetFoldersByOrgID(orgID), 2)
+	})
+
+	t.Run("Limit of 0 keeps no history", func(t *testing.T) {
+		t.Parallel()
+		f := folder.NewDriver(folders, folder.WithHistoryLimit(0))
+		create(t, f, "B")
+		assert.Equal(t, []folder.HistoryEntry{}, f.History())
+		_, err := f.Undo()
+		assert.Equal(t, errors.New(folder.ErrNothingToUndo), err)
+	})
+}
+
+/* Returns the folders of each org, in the order of orgIDs */
+func snapshot(f folder.IDriver, orgIDs ...uuid.UUID) [][]folder.Folder {
+	res := [][]folder.Folder{}
+	for _, orgID := range orgIDs {
+		res = append(res, f.GetFoldersByOrgID(orgID))
+	}
+	return res
+}
+
+/* Drops the versions, which an Undo or Redo moves on rather than restores */
+func withoutVersions(orgs ...[]folder.Folder) [][]folder.Folder {
+	res := [][]folder.Folder{}
+	for _, folders := range orgs {
+		stripped := []folder.Folder{}
+		for _, f := range folders {
+			f.Version = 0
+			stripped = append(stripped, f)
+		}
+		res = append(res, stripped)
+	}
+	return res
+}
+
+/* Checks every folder that changed from before to after went up a version, and no other did */
+func assertVersionsUp(t *testing.T, before [][]folder.Folder, after [][]folder.Folder) {
+	old := make(map[uuid.UUID]folder.Folder)
+	for _, folders := range before {
+		for _, f := range folders {
+			old[f.Id] = f
+		}
+	}
+	for _, folders := range after {
+		for _, f := range folders {
+			prev, exists := old[f.Id]
+			if !exists {
+				continue
+			}
+			unchanged := prev
+			unchanged.Version = f.Version
+			if unchanged == f {
+				assert.Equal(t, prev.Version, f.Version, f.Paths)
+			} else {
+				assert.Greater(t, f.Version, prev.Version, f.Paths)
+			}
+		}
+	}
+}
+
+func sortedIDs(ids ...uuid.UUID) []uuid.UUID {
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
@@ -0,0 +1,171 @@
+package folder
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// DefaultProgressEvery is how many records an import reads between progress
+// reports unless ImportOptions says otherwise.
+const DefaultProgressEvery = 10000
+
+/*
+FolderDecoder reads a JSON array of folders, in the format of sample.json, one
+record at a time. Only the record being decoded is held in memory, so an
+export of any size can be read.
+*/
+type FolderDecoder struct {
+	dec     *json.Decoder
+	started bool
+	done    bool
+	index   int // of the next record
+}
+
+// NewFolderDecoder returns a decoder reading from r.
+func NewFolderDecoder(r io.Reader) *FolderDecoder {
+	return &FolderDecoder{dec: json.NewDecoder(r)}
+}
+
+/*
+RecordError reports a record that couldn't be read. Index counts records from
+0 and Offset is the byte in the input where the problem was found, or where
+the last complete record ended if the input stopped short.
+*/
+type RecordError struct {
+	Index  int
+	Offset int64
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return ErrMalformedRecord + " " + strconv.Itoa(e.Index) + " at byte " +
+		strconv.FormatInt(e.Offset, 10) + ": " + e.Err.Error()
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Next returns the next folder, or io.EOF once the array has been read.
+func (d *FolderDecoder) Next() (Folder, error) {
+	if d.done {
+		return Folder{}, io.EOF
+	}
+	if !d.started {
+		d.started = true
+		tok, err := d.dec.Token()
+		if err != nil {
+			return Folder{}, d.fail(err)
+		}
+		if tok != json.Delim('[') {
+			return Folder{}, d.failAt(0, errors.New(ErrNotFolderArray))
+		}
+	}
+
+	if !d.dec.More() {
+		// the closing bracket, then nothing but whitespace
+		if _, err := d.dec.Token(); err != nil {
+			return Folder{}, d.fail(err)
+		}
+		end := d.dec.InputOffset()
+		if _, err := d.dec.Token(); err != io.EOF {
+			return Folder{}, d.failAt(end, errors.New(ErrTrailingData))
+		}
+		d.done = true
+		return Folder{}, io.EOF
+	}
+
+	// decoded as raw bytes first, their length gives where the record began
+	// and so where in the input a type error inside it is
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return Folder{}, d.fail(err)
+	}
+	start := d.dec.InputOffset() - int64(len(raw))
+	if raw[0] != '{' {
+		return Folder{}, d.failAt(start, errors.New(ErrRecordNotObject))
+	}
+
+	var folder Folder
+	if err := json.Unmarshal(raw, &folder); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return Folder{}, d.failAt(start+typeErr.Offset-1, err)
+		}
+		return Folder{}, d.failAt(start, err)
+	}
+	d.index++
+	return folder, nil
+}
+
+// Offset returns how many bytes of the input have been read.
+func (d *FolderDecoder) Offset() int64 {
+	return d.dec.InputOffset()
+}
+
+/* Ends decoding on an error reading the input */
+func (d *FolderDecoder) fail(err error) error {
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return d.failAt(syntaxErr.Offset-1, err) // Offset counts the bad byte
+	case err == io.EOF:
+		err = io.ErrUnexpectedEOF
+	}
+	return d.failAt(d.dec.InputOffset(), err)
+}
+
+func (d *FolderDecoder) failAt(offset int64, err error) error {
+	d.done = true
+	return &RecordError{Index: d.index, Offset: offset, Err: err}
+}
+
+// ImportProgress is how far an import has got.
+type ImportProgress struct {
+	Records int   // read so far
+	Offset  int64 // bytes of input read so far
+}
+
+// ImportOptions configures ImportFolders.
+type ImportOptions struct {
+	// Progress, if set, is called every ProgressEvery records and when the
+	// import finishes.
+	Progress      func(ImportProgress)
+	ProgressEvery int // 0 means DefaultProgressEvery
+}
+
+/*
+ImportFolders streams the JSON array of folders in r into a new driver, the
+records going straight into the driver's indexes as they are read. A
+malformed record fails the import with a *RecordError.
+*/
+func ImportFolders(r io.Reader, opts ImportOptions, driverOpts ...Option) (IDriver, error) {
+	every := opts.ProgressEvery
+	if every <= 0 {
+		every = DefaultProgressEvery
+	}
+
+	dec := NewFolderDecoder(r)
+	b := newDriverBuilder()
+	for {
+		folder, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		b.add(folder)
+
+		if opts.Progress != nil && b.count%every == 0 {
+			opts.Progress(ImportProgress{Records: b.count, Offset: dec.Offset()})
+		}
+	}
+
+	if opts.Progress != nil && (b.count == 0 || b.count%every != 0) {
+		opts.Progress(ImportProgress{Records: b.count, Offset: dec.Offset()})
+	}
+	return b.build(driverOpts...), nil
+}
@@ -0,0 +1,187 @@
+package folder_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_ImportFolders(t *testing.T) {
+	t.Parallel()
+	sample, err := os.ReadFile("sample.json")
+	assert.NoError(t, err)
+
+	f, err := folder.ImportFolders(strings.NewReader(string(sample)), folder.ImportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, folder.NewDriver(folder.GetSampleData()).Snapshot().Folders(), f.Snapshot().Folders())
+
+	// the imported driver is an ordinary one
+	_, err = f.CreateFolder(f.Snapshot().Folders()[0].OrgId, "", "imported")
+	assert.NoError(t, err)
+}
+
+func Test_folder_ImportFolders_Progress(t *testing.T) {
+	t.Parallel()
+	tests := [...]struct {
+		name string
+		records int
+		every int
+		wantRecords []int
+	} {
+		{
+			name: "Every few records and at the end",
+			records: 7,
+			every: 3,
+			wantRecords: []int{3, 6, 7},
+		},
+		{
+			name: "No extra report when the end falls on one",
+			records: 6,
+			every: 3,
+			wantRecords: []int{3, 6},
+		},
+		{
+			name: "Empty array still reports",
+			records: 0,
+			every: 3,
+			wantRecords: []int{0},
+		},
+		{
+			name: "Default interval",
+			records: 5,
+			wantRecords: []int{5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			records := []string{}
+			for i := range tt.records {
+				records = append(records, fmt.Sprintf(`{"name": "F%d", "paths": "F%d"}`, i, i))
+			}
+			input := "[" + strings.Join(records, ",\n") + "]\n"
+
+			progress := []folder.ImportProgress{}
+			f, err := folder.ImportFolders(strings.NewReader(input), folder.ImportOptions {
+				Progress: func(p folder.ImportProgress) { progress = append(progress, p) },
+				ProgressEvery: tt.every,
+			})
+			assert.NoError(t, err)
+			assert.Len(t, f.Snapshot().Folders(), tt.records)
+
+			get := []int{}
+			for i, p := range progress {
+				get = append(get, p.Records)
+				if i > 0 {
+					assert.Greater(t, p.Offset, progress[i-1].Offset)
+				}
+			}
+			assert.Equal(t, tt.wantRecords, get)
+			assert.LessOrEqual(t, progress[len(progress)-1].Offset, int64(len(input)))
+		})
+	}
+}
+
+func Test_folder_ImportFolders_Malformed(t *testing.T) {
+	t.Parallel()
+	tests := [...]struct {
+		name string
+		input string
+		wantIndex int
+		wantOffset int64
+		wantErr error
+	} {
+		{
+			name: "Not an array",
+			input: `{"name": "A"}`,
+			wantIndex: 0,
+			wantOffset: 0,
+			wantErr: errors.New(folder.ErrNotFolderArray),
+		},
+		{
+			name: "Empty input",
+			input: ``,
+			wantIndex: 0,
+			wantOffset: 0,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "Bad syntax",
+			input: `[{"name": "A"}, {"name": "B"}, {"name": x}]`,
+			wantIndex: 2,
+			wantOffset: 40, // the x
+		},
+		{
+			name: "Wrong type",
+			input: `[{"name": "A"}, {"name": 5}]`,
+			wantIndex: 1,
+			wantOffset: 25, // the 5
+		},
+		{
+			name: "Not an object",
+			input: `[{"name": "A"}, 3]`,
+			wantIndex: 1,
+			wantOffset: 16,
+			wantErr: errors.New(folder.ErrRecordNotObject),
+		},
+		{
+			name: "Cut off",
+			input: `[{"name": "A"}, {"na`,
+			wantIndex: 1,
+			wantOffset: 14, // end of the last whole record
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "Trailing data",
+			input: `[{"name": "A"}] [`,
+			wantIndex: 1,
+			wantOffset: 15, // end of the array
+			wantErr: errors.New(folder.ErrTrailingData),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := folder.ImportFolders(strings.NewReader(tt.input), folder.ImportOptions{})
+
+			var recordErr *folder.RecordError
+			assert.True(t, errors.As(err, &recordErr))
+			assert.Equal(t, tt.wantIndex, recordErr.Index)
+			assert.Equal(t, tt.wantOffset, recordErr.Offset)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr.Error(), recordErr.Err.Error())
+			}
+			assert.Contains(t, err.Error(), fmt.Sprintf("record %d at byte %d", tt.wantIndex, tt.wantOffset))
+		})
+	}
+}
+
+func Test_folder_FolderDecoder(t *testing.T) {
+	t.Parallel()
+	dec := folder.NewFolderDecoder(strings.NewReader(`[{"name": "A", "paths": "A"}, {"name": "B", "paths": "B"}]`))
+
+	a, err := dec.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "A", a.Name)
+	b, err := dec.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "B", b.Name)
+	_, err = dec.Next()
+	assert.Equal(t, io.EOF, err)
+	_, err = dec.Next()
+	assert.Equal(t, io.EOF, err)
+
+	// a malformed record in LoadFolders carries the same detail
+	_, err = folder.LoadFolders(strings.NewReader(`[{"name": 5}]`))
+	var recordErr *folder.RecordError
+	assert.True(t, errors.As(err, &recordErr))
+	var typeErr *json.UnmarshalTypeError
+	assert.True(t, errors.As(err, &typeErr))
+}
@@ -0,0 +1,447 @@
+package folder
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+/*
+orgIndex holds the folders of a single organisation together with the lookup
+tables needed to answer queries without scanning or re-sorting the org.
+Folders are keyed by their driver wide insertion sequence, so walking them in
+key order is insertion order. An index is never modified once built, a
+mutation patches a copy that shares everything it didn't touch, see patch.
+*/
+type orgIndex struct {
+	folders  ptree[int, Folder]    // sequence -> folder
+	byID     ptree[uuid.UUID, int] // ID -> sequence
+	byPath   ptree[string, int]    // path -> sequence
+	byName   ptree[string, []int]  // name -> sequences, ordered by path
+	children ptree[string, []int]  // parent path ("" for roots) -> sequences, in sibling order
+	errs     map[int]error         // structural problem with a folder, by sequence, nil if none
+	broken   []int                 // sequences with an error, ordered by path
+	invalid  bool                  // at least one folder fails ValidateFilePath
+	dupPaths map[string]bool       // paths held by more than one folder, nil if none
+}
+
+/* Builds the index of an org from scratch, seqs must go up like the folders were added */
+func buildOrgIndex(folders []Folder, seqs []int) *orgIndex {
+	idx := &orgIndex{}
+	byID := make(map[uuid.UUID]int, len(folders))
+	byPath := make(map[string]int, len(folders))
+	byName := make(map[string][]int)
+	children := make(map[string][]int)
+	sorted := make([]int, len(folders))
+
+	for i, f := range folders {
+		parent := parentPath(f.Paths)
+		children[parent] = append(children[parent], i)
+		byID[f.Id] = seqs[i]
+		if !ValidateFilePath(f.Paths) {
+			idx.invalid = true
+		}
+		if _, exists := byPath[f.Paths]; !exists {
+			byPath[f.Paths] = seqs[i]
+		} else {
+			if idx.dupPaths == nil {
+				idx.dupPaths = make(map[string]bool)
+			}
+			idx.dupPaths[f.Paths] = true
+		}
+		sorted[i] = i
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return folders[sorted[i]].Paths < folders[sorted[j]].Paths
+	})
+
+	for parent, group := range children {
+		orderSiblings(folders, group)
+		for n, i := range group {
+			group[n] = seqs[i]
+		}
+		children[parent] = group
+	}
+
+	idx.folders = sortedPtree(compareSeqs, seqs, folders)
+	idx.byID = mapPtree(compareIDs, byID)
+	idx.byPath = mapPtree(strings.Compare, byPath)
+	idx.children = mapPtree(strings.Compare, children)
+	for _, i := range sorted {
+		f := folders[i]
+		byName[f.Name] = append(byName[f.Name], seqs[i])
+		if err := idx.validate(f); err != nil {
+			if idx.errs == nil {
+				idx.errs = make(map[int]error)
+			}
+			idx.errs[seqs[i]] = err
+			idx.broken = append(idx.broken, seqs[i])
+		}
+	}
+	idx.byName = mapPtree(strings.Compare, byName)
+
+	return idx
+}
+
+func compareSeqs(a, b int) int {
+	return a - b
+}
+
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+/* Turns a map into a ptree, sorting its keys once */
+func mapPtree[K comparable, V any](cmp func(a, b K) int, m map[K]V) ptree[K, V] {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return cmp(keys[i], keys[j]) < 0
+	})
+	values := make([]V, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+	return sortedPtree(cmp, keys, values)
+}
+
+/*
+Returns a copy of the index with the records in put added, or swapped in for
+the folder holding the same sequence, and the folders holding the sequences
+in removed taken out. Only the entries of those folders, the child lists of
+their parents and their names are touched, so a change costs about as much as
+the folders it changes. An org holding malformed folders, or a change that
+would leave it with one, is rebuilt in full instead, as fixing or breaking a
+folder can change the state of folders far from it.
+*/
+func (idx *orgIndex) patch(put []folderRecord, removed []int) *orgIndex {
+	if len(idx.errs) > 0 || idx.invalid || idx.dupPaths != nil {
+		return idx.rebuild(put, removed)
+	}
+
+	next := *idx
+	dropped := make(map[int]bool, len(put)+len(removed))
+	parents := make(map[string][]int) // child lists to redo, with the sequences joining them
+	names := make(map[string][]int)
+	vacated := []string{}
+	unlink := func(seq int) {
+		old, exists := next.folders.Get(seq)
+		if !exists || dropped[seq] {
+			return
+		}
+		dropped[seq] = true
+		next.byID = next.byID.Delete(old.Id)
+		next.byPath = next.byPath.Delete(old.Paths)
+		parents[parentPath(old.Paths)] = parents[parentPath(old.Paths)]
+		names[old.Name] = names[old.Name]
+		vacated = append(vacated, old.Paths)
+	}
+
+	for _, seq := range removed {
+		unlink(seq)
+		next.folders = next.folders.Delete(seq)
+	}
+	for _, record := range put {
+		unlink(record.seq)
+	}
+	for _, record := range put {
+		folder := record.folder
+		if _, taken := next.byPath.Get(folder.Paths); taken {
+			return idx.rebuild(put, removed)
+		}
+		next.folders = next.folders.Put(record.seq, folder)
+		next.byID = next.byID.Put(folder.Id, record.seq)
+		next.byPath = next.byPath.Put(folder.Paths, record.seq)
+		parent := parentPath(folder.Paths)
+		parents[parent] = append(parents[parent], record.seq)
+		names[folder.Name] = append(names[folder.Name], record.seq)
+	}
+
+	for parent, added := range parents {
+		group, _ := next.children.Get(parent)
+		merged, ok := mergeGroup(group, dropped, added, func(a, b int) bool {
+			return next.folder(a).Position < next.folder(b).Position
+		})
+		if !ok {
+			return idx.rebuild(put, removed)
+		}
+		next.children = putGroup(next.children, parent, merged)
+	}
+	for name, added := range names {
+		group, _ := next.byName.Get(name)
+		merged, _ := mergeGroup(group, dropped, added, func(a, b int) bool {
+			return next.folder(a).Paths < next.folder(b).Paths
+		})
+		next.byName = putGroup(next.byName, name, merged)
+	}
+
+	for _, record := range put {
+		if !ValidateFilePath(record.folder.Paths) || next.validate(record.folder) != nil {
+			return idx.rebuild(put, removed)
+		}
+	}
+	// a folder that left its path mustn't leave children behind
+	for _, path := range vacated {
+		if _, held := next.byPath.Get(path); !held && len(next.childSeqs(path)) > 0 {
+			return idx.rebuild(put, removed)
+		}
+	}
+
+	return &next
+}
+
+/* Builds the index patch would, from scratch */
+func (idx *orgIndex) rebuild(put []folderRecord, removed []int) *orgIndex {
+	records := make([]folderRecord, 0, idx.len()+len(put))
+	replaced := make(map[int]bool, len(put)+len(removed))
+	for _, seq := range removed {
+		replaced[seq] = true
+	}
+	for _, record := range put {
+		replaced[record.seq] = true
+		records = append(records, record)
+	}
+	idx.folders.Ascend(func(seq int, folder Folder) bool {
+		if !replaced[seq] {
+			records = append(records, folderRecord{folder, seq})
+		}
+		return true
+	})
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].seq < records[j].seq
+	})
+	folders := make([]Folder, len(records))
+	seqs := make([]int, len(records))
+	for i, record := range records {
+		folders[i], seqs[i] = record.folder, record.seq
+	}
+	return buildOrgIndex(folders, seqs)
+}
+
+/*
+Returns group without the sequences in drop and with the ones in add merged
+in, keeping it ordered by less. Reports false if an added sequence ties with
+its neighbour, the group can't be told apart by less then.
+*/
+func mergeGroup(group []int, drop map[int]bool, add []int, less func(a, b int) bool) ([]int, bool) {
+	kept := make([]int, 0, len(group))
+	for _, seq := range group {
+		if !drop[seq] {
+			kept = append(kept, seq)
+		}
+	}
+	sort.Slice(add, func(i, j int) bool {
+		return less(add[i], add[j])
+	})
+
+	res := make([]int, 0, len(kept)+len(add))
+	at := 0
+	for _, seq := range add {
+		n := at + sort.Search(len(kept)-at, func(n int) bool {
+			return less(seq, kept[at+n])
+		})
+		res = append(res, kept[at:n]...)
+		if len(res) > 0 && !less(res[len(res)-1], seq) {
+			return nil, false
+		}
+		res = append(res, seq)
+		at = n
+	}
+	return append(res, kept[at:]...), true
+}
+
+func putGroup(groups ptree[string, []int], key string, group []int) ptree[string, []int] {
+	if len(group) == 0 {
+		return groups.Delete(key)
+	}
+	return groups.Put(key, group)
+}
+
+func (idx *orgIndex) len() int {
+	return idx.folders.Len()
+}
+
+func (idx *orgIndex) folder(seq int) Folder {
+	folder, _ := idx.folders.Get(seq)
+	return folder
+}
+
+func (idx *orgIndex) childSeqs(parent string) []int {
+	group, _ := idx.children.Get(parent)
+	return group
+}
+
+func (idx *orgIndex) nameSeqs(name string) []int {
+	group, _ := idx.byName.Get(name)
+	return group
+}
+
+/* Returns the folders in the order they were added */
+func (idx *orgIndex) list() []Folder {
+	res := make([]Folder, 0, idx.len())
+	idx.folders.Ascend(func(_ int, folder Folder) bool {
+		res = append(res, folder)
+		return true
+	})
+	return res
+}
+
+/*
+Sorts a group of siblings, given as positions in folders, by their Position.
+Ties keep the order of the group, which is how folders without a stored
+Position (all 0) end up in the order they were added. Positions are then
+bumped where needed so they strictly increase.
+*/
+func orderSiblings(folders []Folder, group []int) {
+	sort.SliceStable(group, func(i, j int) bool {
+		return folders[group[i]].Position < folders[group[j]].Position
+	})
+	for n := 1; n < len(group); n++ {
+		if prev := folders[group[n-1]].Position; folders[group[n]].Position <= prev {
+			folders[group[n]].Position = prev + 1
+		}
+	}
+}
+
+/* Normalises the Position of freshly loaded folders, the same way an index does */
+func assignPositions(folders []Folder) {
+	type parentKey struct {
+		orgID uuid.UUID
+		path  string
+	}
+
+	groups := make(map[parentKey][]int)
+	for i, f := range folders {
+		key := parentKey{f.OrgId, parentPath(f.Paths)}
+		groups[key] = append(groups[key], i)
+	}
+	for _, group := range groups {
+		orderSiblings(folders, group)
+	}
+}
+
+/* Returns the Position a folder appended under parent gets */
+func (idx *orgIndex) nextPosition(parent string) int {
+	siblings := idx.childSeqs(parent)
+	if len(siblings) == 0 {
+		return 0
+	}
+	return idx.folder(siblings[len(siblings)-1]).Position + 1
+}
+
+/* Checks the folder against its path and the parent it should hang off */
+func (idx *orgIndex) validate(f Folder) error {
+	// ValidateFolderEndOfPath can't take a name longer than the path
+	if len(f.Name) > len(f.Paths) || !ValidateFolderEndOfPath(f) {
+		return errors.New(ErrFolderNotMatchPathEnd + " " + f.Paths)
+	}
+	if idx.dupPaths[f.Paths] {
+		return errors.New(ErrDuplicatePath + " " + f.Paths)
+	}
+
+	parent := parentPath(f.Paths)
+	if parent == "" {
+		return nil
+	}
+	if _, exists := idx.byPath.Get(parent); !exists {
+		return errors.New(ErrUnseenFolder + " " + f.Paths + " for " + lastLabel(parent))
+	}
+	return nil
+}
+
+/* Resolves a full path to exactly one folder */
+func (idx *orgIndex) resolvePath(path string, errNotExist string) (Folder, error) {
+	if !ValidateFilePath(path) {
+		return Folder{}, errors.New(ErrInvalidFilePath)
+	}
+
+	seq, exists := idx.byPath.Get(path)
+	if !exists {
+		return Folder{}, errors.New(errNotExist)
+	}
+	if idx.errs[seq] != nil {
+		return Folder{}, idx.errs[seq]
+	}
+	return idx.folder(seq), nil
+}
+
+/* Returns the whole subtree below path, depth first in sibling order */
+func (idx *orgIndex) descendants(path string) ([]Folder, error) {
+	return idx.walk(path, 0, OrderPath)
+}
+
+/*
+Walks the subtree below path through the children lists, so only the levels
+asked for are visited. Folders with a structural problem, such as a missing
+parent, can't be reached that way, so they are checked up front.
+*/
+func (idx *orgIndex) walk(path string, maxDepth int, order ChildOrder) ([]Folder, error) {
+	if idx.invalid {
+		return nil, errors.New(ErrInvalidFilePath)
+	}
+	for _, seq := range idx.broken {
+		if strings.HasPrefix(idx.folder(seq).Paths, path+".") {
+			return nil, idx.errs[seq]
+		}
+	}
+
+	res := []Folder{}
+	if order == OrderBreadthFirst {
+		level := []string{path}
+		for depth := 1; len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+			next := []string{}
+			for _, parent := range level {
+				for _, seq := range idx.childSeqs(parent) {
+					if idx.errs[seq] != nil {
+						return nil, idx.errs[seq]
+					}
+					folder := idx.folder(seq)
+					res = append(res, folder)
+					next = append(next, folder.Paths)
+				}
+			}
+			level = next
+		}
+		return res, nil
+	}
+
+	var visit func(parent string, depth int) error
+	visit = func(parent string, depth int) error {
+		for _, seq := range idx.childSeqs(parent) {
+			if idx.errs[seq] != nil {
+				return idx.errs[seq]
+			}
+			folder := idx.folder(seq)
+			res = append(res, folder)
+			if maxDepth == 0 || depth < maxDepth {
+				if err := visit(folder.Paths, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(path, 1); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+/* Returns the path with its last label removed, "" for a root path */
+func parentPath(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func lastLabel(path string) string {
+	return path[strings.LastIndexByte(path, '.')+1:]
+}
@@ -0,0 +1,180 @@
+package folder_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+/* Builds a balanced tree of the given depth and fan out under root "A" */
+func generateIndexTree(orgID uuid.UUID, depth int, fanOut int) []folder.Folder {
+	res := []folder.Folder{{Name: "A", OrgId: orgID, Paths: "A"}}
+	level := []string{"A"}
+	for d := 1; d < depth; d++ {
+		next := []string{}
+		for _, parent := range level {
+			for c := 0; c < fanOut; c++ {
+				name := fmt.Sprintf("N%dx%d", d, len(res))
+				res = append(res, folder.Folder{Name: name, OrgId: orgID, Paths: parent + "." + name})
+				next = append(next, parent + "." + name)
+			}
+		}
+		level = next
+	}
+	return res
+}
+
+func Test_folder_IndexAfterMove(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder{
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "D", Position: 1},
+		{Name: "E", OrgId: otherOrgID, Paths: "E"},
+	}
+
+	f := folder.NewDriver(folders)
+	_, err := f.MoveFolder("B", "D")
+	assert.NoError(t, err)
+
+	children, err := f.GetAllChildFolders(orgID, "A")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{}, children)
+
+	children, err = f.GetAllChildFolders(orgID, "D")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{
+		{Id: folder.DeriveFolderID(orgID, "A.B"), Name: "B", OrgId: orgID, Paths: "D.B", Version: 1},
+		{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "D.B.C", Version: 1},
+	}, children)
+
+	// the driver keeps its own copy of the folders
+	assert.Equal(t, "A.B", folders[1].Paths)
+	assert.Equal(t, withIDs([]folder.Folder{{Name: "E", OrgId: otherOrgID, Paths: "E"}}), f.GetFoldersByOrgID(otherOrgID))
+}
+
+func Test_folder_IndexLargeOrg(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := generateIndexTree(orgID, 6, 5) // 3906 folders
+
+	f := folder.NewDriver(folders)
+	assert.Len(t, f.GetFoldersByOrgID(orgID), len(folders))
+
+	children, err := f.GetAllChildFolders(orgID, "A")
+	assert.NoError(t, err)
+	assert.Len(t, children, len(folders) - 1)
+
+	leaf := folders[len(folders) - 1]
+	children, err = f.GetAllChildFolders(orgID, leaf.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{}, children)
+}
+
+func Benchmark_folder_GetAllChildFolders(b *testing.B) {
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := generateIndexTree(orgID, 8, 5) // ~100k folders
+	f := folder.NewDriver(folders)
+	name := folders[len(folders) / 2].Name
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.GetAllChildFolders(orgID, name); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_folder_CreateFolder(b *testing.B) {
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := generateIndexTree(orgID, 8, 5) // ~100k folders
+	f := folder.NewDriver(folders, folder.WithHistoryLimit(1))
+	parent := folders[len(folders) / 2].Paths
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.CreateFolder(orgID, parent, fmt.Sprintf("new%d", i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+/* Checks an index patched change by change answers like one built from scratch */
+func Test_folder_IndexPatchMatchesRebuild(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	f := folder.NewDriver(append(generateIndexTree(orgID, 3, 6), folder.Folder{Name: "X", OrgId: otherOrgID, Paths: "X"}))
+	rnd := rand.New(rand.NewSource(1))
+	names := []string{"A", "B", "C", "N1x1", "N2x9"}
+
+	for i := 0; i < 400; i++ {
+		folders := f.GetFoldersByOrgID(orgID)
+		pick := func() string {
+			if len(folders) == 0 {
+				return ""
+			}
+			return folders[rnd.Intn(len(folders))].Paths
+		}
+		name := names[rnd.Intn(len(names))]
+		switch rnd.Intn(9) {
+		case 0, 1:
+			f.CreateFolder(orgID, pick(), name)
+		case 2:
+			f.MoveFolderByPath(orgID, pick(), pick())
+		case 3:
+			f.MoveFolderToIndex(orgID, pick(), pick(), rnd.Intn(4))
+		case 4:
+			f.RenameFolder(orgID, pick(), name)
+		case 5:
+			f.DeleteFolder(orgID, pick(), folder.DeletePolicy(rnd.Intn(3)))
+		case 6:
+			f.CopyFolder(orgID, pick(), pick(), folder.CopyOptions{})
+		case 7:
+			if rnd.Intn(4) != 0 {
+				f.CreateFolder(orgID, pick(), name)
+				break
+			}
+			f.TransferFolder(orgID, pick(), otherOrgID, "X", folder.TransferOptions{})
+		case 8:
+			if rnd.Intn(2) == 0 {
+				f.Undo()
+			} else {
+				f.Redo()
+			}
+		}
+
+		folders = f.GetFoldersByOrgID(orgID)
+		rebuilt := folder.NewDriver(append(folders, f.GetFoldersByOrgID(otherOrgID)...))
+		assert.Equal(t, folders, rebuilt.GetFoldersByOrgID(orgID), "step %d", i)
+		for _, fo := range folders {
+			want, wantErr := rebuilt.GetChildFolders(orgID, fo.Paths, folder.ChildQueryOptions{})
+			got, err := f.GetChildFolders(orgID, fo.Paths, folder.ChildQueryOptions{})
+			assert.Equal(t, wantErr, err, "step %d %s", i, fo.Paths)
+			assert.Equal(t, want, got, "step %d %s", i, fo.Paths)
+
+			want, wantErr = rebuilt.GetSiblings(orgID, fo.Paths)
+			got, err = f.GetSiblings(orgID, fo.Paths)
+			assert.Equal(t, wantErr, err, "step %d %s", i, fo.Paths)
+			assert.Equal(t, want, got, "step %d %s", i, fo.Paths)
+
+			want, wantErr = rebuilt.GetAllChildFolders(orgID, fo.Name)
+			got, err = f.GetAllChildFolders(orgID, fo.Name)
+			assert.Equal(t, wantErr, err, "step %d %s", i, fo.Name)
+			assert.Equal(t, want, got, "step %d %s", i, fo.Name)
+
+			byID, err := f.GetFolderByID(fo.Id)
+			assert.NoError(t, err)
+			assert.Equal(t, fo, byID)
+		}
+		if t.Failed() {
+			return
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package folder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gofrs/uuid"
+)
+
+// DefaultCompactEvery is how many journal records a JournalStore collects
+// before folding them into its snapshot, unless told otherwise.
+const DefaultCompactEvery = 1000
+
+/*
+JournalStore keeps a snapshot file of every folder plus an append-only journal
+of the changes made since. A change only appends one record to the journal,
+and every compactEvery records the current state is written out as the new
+snapshot and the journal starts over.
+
+Each journal record is a line holding a CRC-32 of its JSON and the JSON
+itself. A crash mid-append leaves a torn last line, which Load cuts off, and
+an append that fails is cut off before the next one. Replaying a record is
+idempotent, so a crash between writing a snapshot and emptying the journal
+does no harm either.
+*/
+type JournalStore struct {
+	snapshotPath string
+	journalPath  string
+	compactEvery int
+	records      int   // in the journal since the last compaction
+	size         int64 // of the journal up to its last good record, -1 if not known yet
+}
+
+/*
+CompactError is returned by JournalStore.Save when the change was appended to
+the journal but the compaction due after it failed. The change is durable all
+the same, so a driver keeps it and returns it along with the error.
+*/
+type CompactError struct {
+	Err error
+}
+
+func (e *CompactError) Error() string {
+	return ErrCompactFailed + ": " + e.Err.Error()
+}
+
+func (e *CompactError) Unwrap() error {
+	return e.Err
+}
+
+type journalRecord struct {
+	Op      string          `json:"op"`
+	Changes []journalChange `json:"changes"`
+}
+
+type journalChange struct {
+	Kind   string `json:"kind"`
+	Folder Folder `json:"folder"` // as it is now, or as it was for a deleted folder
+}
+
+// NewJournalStore returns a store keeping its files in dir, a compactEvery of
+// 0 or less uses DefaultCompactEvery.
+func NewJournalStore(dir string, compactEvery int) *JournalStore {
+	if compactEvery <= 0 {
+		compactEvery = DefaultCompactEvery
+	}
+	return &JournalStore{
+		snapshotPath: filepath.Join(dir, "snapshot.json"),
+		journalPath:  filepath.Join(dir, "journal.log"),
+		compactEvery: compactEvery,
+		size:         -1,
+	}
+}
+
+/*
+Load returns the last snapshot with the journal replayed over it. A torn
+record at the end of the journal is truncated away, a bad record anywhere
+else is reported as an ErrJournalCorrupt error as it means more than a
+crash mid-append.
+*/
+func (s *JournalStore) Load() ([]Folder, error) {
+	folders, err := NewFileStore(s.snapshotPath).Load()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.journalPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.size = 0
+		return folders, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	replay := newReplay(folders)
+	s.records = 0
+	offset := 0
+	for offset < len(data) {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			break // torn, the newline is written last
+		}
+		record, ok := decodeJournalLine(data[offset : offset+end])
+		if !ok {
+			if offset+end+1 < len(data) {
+				return nil, errors.New(ErrJournalCorrupt + " " + strconv.Itoa(offset))
+			}
+			break // torn last record
+		}
+		replay.apply(record)
+		s.records++
+		offset += end + 1
+	}
+
+	if offset < len(data) {
+		if err := os.Truncate(s.journalPath, int64(offset)); err != nil {
+			return nil, err
+		}
+	}
+	s.size = int64(offset)
+	return replay.folders(), nil
+}
+
+/*
+Save appends the change to the journal, compacting it when it's due. Once the
+record is appended the change is durable, so a failed compaction is reported
+as a CompactError rather than a failed save, and it is tried again on the
+next one.
+*/
+func (s *JournalStore) Save(event ChangeEvent, state *Snapshot) error {
+	record := journalRecord{Op: event.Op, Changes: make([]journalChange, len(event.Changes))}
+	for i, change := range event.Changes {
+		folder := change.New
+		if change.Kind == ChangeDeleted {
+			folder = change.Old
+		}
+		record.Changes[i] = journalChange{Kind: change.Kind.String(), Folder: folder}
+	}
+	if err := s.append(record); err != nil {
+		return err
+	}
+
+	s.records++
+	if s.records >= s.compactEvery {
+		// records stays due if it fails
+		if err := s.Compact(state); err != nil {
+			return &CompactError{Err: err}
+		}
+	}
+	return nil
+}
+
+// Compact writes state out as the new snapshot and empties the journal. The
+// journal is kept if the snapshot can't be written.
+func (s *JournalStore) Compact(state *Snapshot) error {
+	if err := writeFileAtomic(s.snapshotPath, MarshalJson(state.Folders()), FilePerm); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.journalPath, nil, FilePerm); err != nil {
+		return err
+	}
+	s.records = 0
+	s.size = 0
+	return nil
+}
+
+func (s *JournalStore) append(record journalRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("%08x %s\n", crc32.ChecksumIEEE(b), b)
+
+	file, err := os.OpenFile(s.journalPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, FilePerm)
+	if err != nil {
+		return err
+	}
+	if s.size < 0 {
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+		s.size = info.Size()
+	}
+
+	// cuts off whatever a failed append couldn't take back
+	err = file.Truncate(s.size)
+	if err == nil {
+		_, err = file.WriteString(line)
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a record that may not be on disk mustn't replay, if this fails as
+		// well the next append tries again
+		os.Truncate(s.journalPath, s.size)
+		return err
+	}
+	s.size += int64(len(line))
+	return nil
+}
+
+/* Parses a journal line, reporting false for anything a torn write could leave behind */
+func decodeJournalLine(line []byte) (journalRecord, bool) {
+	var record journalRecord
+	sum, b, found := bytes.Cut(line, []byte(" "))
+	if !found || len(sum) != 8 {
+		return record, false
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(want) != crc32.ChecksumIEEE(b) {
+		return record, false
+	}
+	if err := json.Unmarshal(b, &record); err != nil {
+		return record, false
+	}
+	return record, true
+}
+
+/*
+Replays journal records over a list of folders. Folders are upserted and
+removed by ID, which is what makes replaying a record twice harmless. A
+removed folder leaves its slot behind, so when undo brings it back it lands
+where it was, as it does in the driver.
+*/
+type replay struct {
+	list  []replaySlot
+	slots map[replayKey]int // where a folder sits, or last sat, in an org
+	live  map[uuid.UUID]int // where each folder currently is
+}
+
+type replaySlot struct {
+	folder Folder
+	live   bool
+}
+
+type replayKey struct {
+	id    uuid.UUID
+	orgID uuid.UUID
+}
+
+func newReplay(folders []Folder) *replay {
+	r := &replay{
+		slots: make(map[replayKey]int, len(folders)),
+		live:  make(map[uuid.UUID]int, len(folders)),
+	}
+	for _, folder := range folders {
+		r.put(folder)
+	}
+	return r
+}
+
+func (r *replay) apply(record journalRecord) {
+	for _, change := range record.Changes {
+		folder := change.Folder
+		if i, exists := r.live[folder.Id]; exists && (change.Kind == ChangeDeleted.String() || r.list[i].folder.OrgId != folder.OrgId) {
+			r.list[i].live = false
+			delete(r.live, folder.Id)
+		}
+		if change.Kind != ChangeDeleted.String() {
+			r.put(folder)
+		}
+	}
+}
+
+/* Puts a folder in its slot for its org, new ones go last like in the driver */
+func (r *replay) put(folder Folder) {
+	key := replayKey{folder.Id, folder.OrgId}
+	i, exists := r.slots[key]
+	if !exists {
+		i = len(r.list)
+		r.slots[key] = i
+		r.list = append(r.list, replaySlot{})
+	}
+	r.list[i] = replaySlot{folder: folder, live: true}
+	r.live[folder.Id] = i
+}
+
+func (r *replay) folders() []Folder {
+	res := make([]Folder, 0, len(r.live))
+	for _, slot := range r.list {
+		if slot.live {
+			res = append(res, slot.folder)
+		}
+	}
+	return res
+}
+
+// OpenJournalDriver opens a driver on a JournalStore in dir, creating the
+// directory if needed.
+func OpenJournalDriver(dir string, compactEvery int, opts ...Option) (IDriver, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return Open(NewJournalStore(dir, compactEvery), opts...)
+}
@@ -0,0 +1,213 @@
+package folder_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+/* Makes a mix of changes, each one a journal record */
+func journalChanges(t *testing.T, f folder.IDriver, orgID uuid.UUID, otherOrgID uuid.UUID) {
+	for _, path := range []string{"A", "B", "C", "A.D", "A.D.E"} {
+		_, err := f.CreateFolder(orgID, parentOf(path), path[strings.LastIndexByte(path, '.')+1:])
+		assert.NoError(t, err)
+	}
+	_, err := f.MoveFolderByPath(orgID, "A.D", "B")
+	assert.NoError(t, err)
+	_, err = f.RenameFolder(orgID, "B.D", "F")
+	assert.NoError(t, err)
+	_, err = f.MoveFolderAfter(orgID, "A", "C")
+	assert.NoError(t, err)
+	_, err = f.CreateFolder(otherOrgID, "", "X")
+	assert.NoError(t, err)
+	_, err = f.TransferFolder(orgID, "B.F", otherOrgID, "X", folder.TransferOptions{})
+	assert.NoError(t, err)
+	_, err = f.DeleteFolder(orgID, "B", folder.DeleteIfEmpty)
+	assert.NoError(t, err)
+	_, err = f.Undo()
+	assert.NoError(t, err)
+}
+
+func parentOf(path string) string {
+	if i := strings.LastIndexByte(path, '.'); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+func Test_folder_OpenJournalDriver(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	tests := [...]struct {
+		name string
+		compactEvery int
+		wantRecords int
+	} {
+		{
+			name: "Replay the whole journal",
+			compactEvery: 100,
+			wantRecords: 12,
+		},
+		{
+			name: "Replay over a compacted snapshot",
+			compactEvery: 5,
+			wantRecords: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := filepath.Join(t.TempDir(), "journal")
+			f, err := folder.OpenJournalDriver(dir, tt.compactEvery)
+			assert.NoError(t, err)
+			journalChanges(t, f, orgID, otherOrgID)
+			assert.Equal(t, tt.wantRecords, countLines(t, filepath.Join(dir, "journal.log")))
+
+			reopened, err := folder.OpenJournalDriver(dir, tt.compactEvery)
+			assert.NoError(t, err)
+			assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+			assert.Equal(t, f.GetFoldersByOrgID(otherOrgID), reopened.GetFoldersByOrgID(otherOrgID))
+		})
+	}
+}
+
+func Test_folder_OpenJournalDriver_Torn(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	tests := [...]struct {
+		name string
+		tail string
+		wantErr error
+	} {
+		{
+			name: "Record without its newline",
+			tail: `0badf00d {"op":"CreateFolder","chan`,
+		},
+		{
+			name: "Record with a bad checksum",
+			tail: "0badf00d {\"op\":\"CreateFolder\",\"changes\":[]}\n",
+		},
+		{
+			name: "Bad record before the last one",
+			tail: "0badf00d {}\n0badf00d {}\n",
+			wantErr: errors.New(folder.ErrJournalCorrupt + " "),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			f, err := folder.OpenJournalDriver(dir, 100)
+			assert.NoError(t, err)
+			_, err = f.CreateFolder(orgID, "", "A")
+			assert.NoError(t, err)
+			_, err = f.CreateFolder(orgID, "A", "B")
+			assert.NoError(t, err)
+
+			journal := filepath.Join(dir, "journal.log")
+			info, err := os.Stat(journal)
+			assert.NoError(t, err)
+			file, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND, 0)
+			assert.NoError(t, err)
+			_, err = file.WriteString(tt.tail)
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+
+			reopened, err := folder.OpenJournalDriver(dir, 100)
+			if tt.wantErr != nil {
+				assert.Nil(t, reopened)
+				assert.Equal(t, tt.wantErr.Error() + strconv.FormatInt(info.Size(), 10), err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+
+			// the torn record is gone, new records follow the good ones
+			truncated, err := os.Stat(journal)
+			assert.NoError(t, err)
+			assert.Equal(t, info.Size(), truncated.Size())
+			_, err = reopened.CreateFolder(orgID, "A", "C")
+			assert.NoError(t, err)
+			assert.Equal(t, 3, countLines(t, journal))
+		})
+	}
+}
+
+func Test_folder_OpenJournalDriver_CompactFails(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "snapshot.json")
+	journal := filepath.Join(dir, "journal.log")
+
+	f, err := folder.OpenJournalDriver(dir, 1)
+	assert.NoError(t, err)
+	// a directory in the snapshot's place can't be replaced
+	assert.NoError(t, os.MkdirAll(filepath.Join(snapshot, "blocked"), 0o755))
+	// the journal has each change, so they are kept but the error is told
+	var compactErr *folder.CompactError
+	_, err = f.CreateFolder(orgID, "", "A")
+	assert.ErrorAs(t, err, &compactErr)
+	_, err = f.CreateFolder(orgID, "", "B")
+	assert.ErrorAs(t, err, &compactErr)
+	_, err = f.MoveFolderByPath(orgID, "B", "A")
+	assert.ErrorAs(t, err, &compactErr)
+	assert.Equal(t, 3, countLines(t, journal))
+	assert.Equal(t, []string{"A", "A.B"}, folderPaths(f.GetFoldersByOrgID(orgID)))
+	assert.Len(t, f.History(), 3)
+
+	assert.NoError(t, os.RemoveAll(snapshot))
+	reopened, err := folder.OpenJournalDriver(dir, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+
+	// compaction is retried by the next save
+	_, err = f.RenameFolder(orgID, "A.B", "C")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, countLines(t, journal))
+	reopened, err = folder.OpenJournalDriver(dir, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+}
+
+func Test_folder_OpenJournalDriver_FailedAppend(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "journal.log")
+
+	f, err := folder.OpenJournalDriver(dir, 100)
+	assert.NoError(t, err)
+	_, err = f.CreateFolder(orgID, "", "A")
+	assert.NoError(t, err)
+
+	// what an append that failed part way through leaves behind
+	file, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND, 0)
+	assert.NoError(t, err)
+	_, err = file.WriteString(`0badf00d {"op":"CreateFolder","chan`)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	_, err = f.CreateFolder(orgID, "A", "B")
+	assert.NoError(t, err)
+	_, err = f.CreateFolder(orgID, "A", "C")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, countLines(t, journal))
+
+	reopened, err := folder.OpenJournalDriver(dir, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, f.GetFoldersByOrgID(orgID), reopened.GetFoldersByOrgID(orgID))
+}
+
+func countLines(t *testing.T, path string) int {
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Count(string(b), "\n")
+}
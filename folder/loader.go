@@ -0,0 +1,157 @@
+package folder
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// The sample data is compiled in, so it loads wherever the binary runs.
+//
+//go:embed sample.json sample_only_defaultOrgID.json
+var sampleFS embed.FS
+
+/*
+LoadFolders reads a JSON array of folders, in the format of sample.json, from r.
+Folders without an ID or Position get one, the same way GetSampleData does. A
+malformed record is reported as a *RecordError.
+*/
+func LoadFolders(r io.Reader) ([]Folder, error) {
+	folders, err := decodeFolders(r)
+	if err != nil {
+		return nil, err
+	}
+	assignMissingIDs(folders) // older files have no IDs
+	assignPositions(folders)  // or positions
+	return folders, nil
+}
+
+/* Reads every record of r as is */
+func decodeFolders(r io.Reader) ([]Folder, error) {
+	folders := []Folder{}
+	dec := NewFolderDecoder(r)
+	for {
+		folder, err := dec.Next()
+		if err == io.EOF {
+			return folders, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, folder)
+	}
+}
+
+// LoadFoldersFile reads the folders in the JSON file at name.
+func LoadFoldersFile(name string) ([]Folder, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return loadNamed(file, name)
+}
+
+// LoadFoldersStdin reads the folders piped to the program.
+func LoadFoldersStdin() ([]Folder, error) {
+	return loadNamed(os.Stdin, "stdin")
+}
+
+// LoadFoldersDir reads a directory of per-org files, see LoadFoldersFS.
+func LoadFoldersDir(dir string) ([]Folder, error) {
+	folders, err := LoadFoldersFS(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return folders, nil
+}
+
+/*
+LoadFoldersFS reads name from fsys, an embed.FS or os.DirFS for example.
+
+If name is a directory, every .json file directly inside it is read in file
+name order. A file named after an org ID, such as
+c1556e17-b7c0-45a3-a6ae-9546248fb17a.json, holds only that org's folders:
+folders in it without an org_id are given it, and one naming another org is
+an error.
+*/
+func LoadFoldersFS(fsys fs.FS, name string) ([]Folder, error) {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadFSFile(fsys, name)
+	}
+
+	entries, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	folders := []Folder{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		loaded, err := loadFSFile(fsys, path.Join(name, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		folders = append(folders, loaded...)
+	}
+	// IDs were only unique per file, and a repeat across files needs a new one
+	assignMissingIDs(folders)
+	return folders, nil
+}
+
+/* Reads one file of fsys, applying the org its name gives, if any */
+func loadFSFile(fsys fs.FS, name string) ([]Folder, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	orgID := uuid.FromStringOrNil(strings.TrimSuffix(path.Base(name), ".json"))
+	if orgID.IsNil() {
+		return loadNamed(file, name)
+	}
+
+	folders, err := decodeFolders(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for i := range folders {
+		if folders[i].OrgId.IsNil() {
+			folders[i].OrgId = orgID
+		} else if folders[i].OrgId != orgID {
+			return nil, errors.New(ErrFolderInWrongOrgFile + " " + name + ": " + folders[i].Paths)
+		}
+	}
+	// IDs are derived from the org, so only now that every folder has one
+	assignMissingIDs(folders)
+	assignPositions(folders)
+	return folders, nil
+}
+
+/* LoadFolders, with name added to any decoding error */
+func loadNamed(r io.Reader, name string) ([]Folder, error) {
+	folders, err := LoadFolders(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return folders, nil
+}
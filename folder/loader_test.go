@@ -0,0 +1,151 @@
+package folder_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_LoadFolders(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	tests := [...]struct {
+		name string
+		input string
+		want []folder.Folder
+		wantErr bool
+	} {
+		{
+			name: "IDs and positions are filled in",
+			input: `[
+				{"name": "A", "org_id": "` + folder.DefaultOrgID + `", "paths": "A"},
+				{"name": "B", "org_id": "` + folder.DefaultOrgID + `", "paths": "A.B"},
+				{"name": "C", "org_id": "` + folder.DefaultOrgID + `", "paths": "A.C"}
+			]`,
+			want: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+				{Name: "C", OrgId: orgID, Paths: "A.C", Position: 1},
+			},
+		},
+		{
+			name: "Empty array",
+			input: `[]`,
+			want: []folder.Folder{},
+		},
+		{
+			name: "Not JSON",
+			input: `[{"name": "A",`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			get, err := folder.LoadFolders(strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, withIDs(tt.want), get)
+		})
+	}
+}
+
+func Test_folder_LoadFoldersFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.json")
+	assert.NoError(t, os.WriteFile(broken, []byte("{"), 0o644))
+
+	get, err := folder.LoadFoldersFile("sample.json")
+	assert.NoError(t, err)
+	assert.Equal(t, folder.GetSampleData(), get)
+
+	_, err = folder.LoadFoldersFile(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = folder.LoadFoldersFile(broken)
+	assert.ErrorContains(t, err, broken)
+}
+
+func Test_folder_LoadFoldersDir(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.FromStringOrNil("0f9a6c2e-4d1b-4c8e-9a3f-2b7d5e1c8a40") // sorts before DefaultOrgID
+	tests := [...]struct {
+		name string
+		files map[string]string
+		want []folder.Folder
+		wantErr string
+	} {
+		{
+			name: "Org comes from the file name",
+			files: map[string]string {
+				otherOrgID.String() + ".json": `[{"name": "X", "paths": "X"}]`,
+				folder.DefaultOrgID + ".json": `[{"name": "A", "paths": "A"}, {"name": "B", "paths": "A.B"}]`,
+				"notes.txt": `not folders`,
+			},
+			want: []folder.Folder {
+				{Name: "X", OrgId: otherOrgID, Paths: "X"},
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+			},
+		},
+		{
+			name: "Files not named after an org keep their org_id",
+			files: map[string]string {
+				"all.json": `[{"name": "A", "org_id": "` + folder.DefaultOrgID + `", "paths": "A"}]`,
+			},
+			want: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+			},
+		},
+		{
+			name: "Folder of another org",
+			files: map[string]string {
+				otherOrgID.String() + ".json": `[{"name": "A", "org_id": "` + folder.DefaultOrgID + `", "paths": "A"}]`,
+			},
+			wantErr: folder.ErrFolderInWrongOrgFile,
+		},
+		{
+			name: "Broken file",
+			files: map[string]string {
+				"a.json": `[]`,
+				"b.json": `[{`,
+			},
+			wantErr: "b.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			mapFS := fstest.MapFS{}
+			for name, data := range tt.files {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+				mapFS["data/" + name] = &fstest.MapFile{Data: []byte(data)}
+			}
+
+			fromDir, err := folder.LoadFoldersDir(dir)
+			fromFS, fsErr := folder.LoadFoldersFS(mapFS, "data")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.ErrorContains(t, fsErr, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, fsErr)
+			assert.Equal(t, withIDs(tt.want), fromDir)
+			assert.Equal(t, fromDir, fromFS)
+		})
+	}
+}
@@ -2,65 +2,393 @@ package folder
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"github.com/gofrs/uuid"
 )
 
-func (f *driver) MoveFolder(name string, dst string) ([]Folder, error) {
+/*
+MoveFolder has no orgID to go on, so the source is looked up across every
+organisation and must be unique. The destination is then looked up within the
+source's organisation only.
+*/
+func (f *driver) MoveFolder(name string, dst string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolder", &err)
+
 	if name == dst {
 		return []Folder{}, errors.New(ErrSourceToItself)
 	}
 
-	folders := f.folders
 	var nameFolder Folder // source folder
-	var dstFolder Folder
-
-	//Searching for origin IDs
-	for _, f := range folders {
-		if f.Name == name {
-			nameFolder = f
-		} else if f.Name == dst {
-			dstFolder = f
+	sourceCount := 0
+	for _, idx := range f.orgs {
+		if matches := idx.nameSeqs(name); len(matches) > 0 {
+			nameFolder = idx.folder(matches[0])
+			sourceCount += len(matches)
 		}
 	}
-	if nameFolder.OrgId == uuid.Nil {
-		return []Folder{}, errors.New(ErrSourceNotExists) 
+	if sourceCount == 0 {
+		return []Folder{}, errors.New(ErrSourceNotExists)
+	}
+	if sourceCount > 1 {
+		return []Folder{}, errors.New(ErrAmbiguousSource)
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+
+	idx := f.orgs[nameFolder.OrgId]
+	if len(idx.nameSeqs(dst)) == 0 {
+		// only used to explain why the destination can't be used
+		for orgID, other := range f.orgs {
+			if orgID != nameFolder.OrgId && len(other.nameSeqs(dst)) > 0 {
+				return []Folder{}, errors.New(ErrFolderToDiffOrg)
+			}
+		}
+	}
+	dstFolder, err := idx.resolveName(dst, ErrDestNotExist, ErrAmbiguousDest)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	if err := f.moveSubtree(nameFolder, dstFolder.Paths, -1); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.allFolders(), nil
+}
+
+/*
+MoveFolderInOrg only ever looks inside the given organisation, so folders of
+other tenants can neither be picked by mistake nor influence the outcome.
+*/
+func (f *driver) MoveFolderInOrg(orgID uuid.UUID, name string, dst string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderInOrg", &err)
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if name == dst {
+		return []Folder{}, errors.New(ErrSourceToItself)
 	}
-	if dstFolder.OrgId == uuid.Nil {
-		return []Folder{}, errors.New(ErrDestNotExist)
+
+	nameFolder, err := idx.resolveName(name, ErrSourceNotExists, ErrAmbiguousSource)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+	dstFolder, err := idx.resolveName(dst, ErrDestNotExist, ErrAmbiguousDest)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	if err := f.moveSubtree(nameFolder, dstFolder.Paths, -1); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.orgFolders(orgID), nil
+}
+
+func (f *driver) MoveFolderByPath(orgID uuid.UUID, src string, dst string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderByPath", &err)
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if src == dst {
+		return []Folder{}, errors.New(ErrSourceToItself)
+	}
+
+	nameFolder, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+	dstFolder, err := idx.resolvePath(dst, ErrDestNotExist)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	if err := f.moveSubtree(nameFolder, dstFolder.Paths, -1); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.orgFolders(orgID), nil
+}
+
+func (f *driver) MoveFolderByID(src uuid.UUID, dst uuid.UUID, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderByID", &err)
+
+	if src == dst {
+		return []Folder{}, errors.New(ErrSourceToItself)
+	}
+
+	nameFolder, _, err := f.folderByID(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+	dstFolder, _, err := f.folderByID(dst, ErrDestNotExist)
+	if err != nil {
+		return []Folder{}, err
 	}
 	if nameFolder.OrgId != dstFolder.OrgId {
 		return []Folder{}, errors.New(ErrFolderToDiffOrg)
 	}
 
-	childFolders, err := f.GetAllChildFolders(nameFolder.OrgId, name)
+	if err := f.moveSubtree(nameFolder, dstFolder.Paths, -1); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.orgFolders(nameFolder.OrgId), nil
+}
+
+func (f *driver) MoveFolderToRoot(orgID uuid.UUID, src string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderToRoot", &err)
+
+	idx, err := f.orgIndex(orgID)
 	if err != nil {
 		return []Folder{}, err
 	}
 
-	// checking if destination is child of source
-	for _, f := range childFolders {
-		if f.Name == dst {
-			return []Folder{}, errors.New(ErrSourceToChild)
+	nameFolder, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+
+	// already a root, nothing to do
+	if parentPath(nameFolder.Paths) != "" {
+		if err := f.moveSubtree(nameFolder, "", -1); err != nil {
+			return []Folder{}, err
 		}
 	}
 
+	return f.orgFolders(orgID), nil
+}
+
+func (f *driver) MoveFolderToIndex(orgID uuid.UUID, src string, dst string, index int, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderToIndex", &err)
+
+	if index < 0 {
+		return []Folder{}, errors.New(ErrInvalidPosition)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if src == dst {
+		return []Folder{}, errors.New(ErrSourceToItself)
+	}
+
+	nameFolder, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+	dstFolder, err := idx.resolvePath(dst, ErrDestNotExist)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	if err := f.moveSubtree(nameFolder, dstFolder.Paths, index); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.orgFolders(orgID), nil
+}
+
+func (f *driver) MoveFolderBefore(orgID uuid.UUID, src string, sibling string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderBefore", &err)
+	return f.moveNextTo(orgID, src, sibling, 0, opts)
+}
+
+func (f *driver) MoveFolderAfter(orgID uuid.UUID, src string, sibling string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("MoveFolderAfter", &err)
+	return f.moveNextTo(orgID, src, sibling, 1, opts)
+}
+
+/* Moves src into the parent of sibling, offset places it before (0) or after (1) sibling */
+func (f *driver) moveNextTo(orgID uuid.UUID, src string, sibling string, offset int, opts []WriteOption) ([]Folder, error) {
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if src == sibling {
+		return []Folder{}, errors.New(ErrSourceToItself)
+	}
+
+	nameFolder, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(nameFolder, opts); err != nil {
+		return []Folder{}, err
+	}
+	siblingFolder, err := idx.resolvePath(sibling, ErrDestNotExist)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	// index of the sibling once the source has left its current spot
+	parent := parentPath(siblingFolder.Paths)
+	index := 0
+	for _, seq := range idx.childSeqs(parent) {
+		if idx.folder(seq).Paths == siblingFolder.Paths {
+			break
+		}
+		if idx.folder(seq).Paths != nameFolder.Paths {
+			index++
+		}
+	}
+
+	if err := f.moveSubtree(nameFolder, parent, index + offset); err != nil {
+		return []Folder{}, err
+	}
+
+	return f.orgFolders(orgID), nil
+}
+
+/* Resolves a folder name within one organisation, refusing to guess between duplicates */
+func (idx *orgIndex) resolveName(name string, errNotExist string, errAmbiguous string) (Folder, error) {
+	matches := idx.nameSeqs(name)
+	if len(matches) == 0 {
+		return Folder{}, errors.New(errNotExist)
+	}
+	if len(matches) > 1 {
+		return Folder{}, errors.New(errAmbiguous)
+	}
+	return idx.folder(matches[0]), nil
+}
+
+/*
+Rebases the source folder and its subtree under parent, "" being the top level,
+and places it at index among its new siblings. A negative index places it
+last. Both folders must share an org.
+*/
+func (f *driver) moveSubtree(nameFolder Folder, parent string, index int) error {
+	idx := f.orgs[nameFolder.OrgId]
+	childFolders, err := idx.descendants(nameFolder.Paths)
+	if err != nil {
+		return err
+	}
+
+	// checking if destination is child of source
+	if parent == nameFolder.Paths || strings.HasPrefix(parent, nameFolder.Paths + ".") {
+		return errors.New(ErrSourceToChild)
+	}
+
+	oldNamePath := nameFolder.Paths
 	orgNamePathLen := len(nameFolder.Paths) // needed for path splitting
-	newNamePath := dstFolder.Paths + "." + nameFolder.Name // new path prefix
-	nameFolder.Paths = newNamePath
+	newNamePath := nameFolder.Name // new path prefix
+	if parent != "" {
+		newNamePath = parent + "." + nameFolder.Name
+	}
+
+	// paths must stay unique, moving onto its own path is fine as nothing changes
+	if _, exists := idx.byPath.Get(newNamePath); exists && newNamePath != nameFolder.Paths {
+		return errors.New(ErrFolderNameConflict + " " + newNamePath)
+	}
+
+	// new siblings keep their order, the source is slotted in at index
+	siblings := []Folder{}
+	for _, seq := range idx.childSeqs(parent) {
+		if sibling := idx.folder(seq); sibling.Paths != oldNamePath {
+			siblings = append(siblings, sibling)
+		}
+	}
+	if index > len(siblings) {
+		return errors.New(ErrInvalidPosition)
+	}
 
 	// map used to save computation time for updating source + child folders
-	updatingFolders := make(map[string]Folder) // map of name : Folder
-	updatingFolders[name] = nameFolder
-	for _, f := range childFolders {
-		f.Paths = newNamePath + "." + f.Paths[orgNamePathLen + 1:] // (+1) due to extra '.'
-		updatingFolders[f.Name] = f
+	updatingFolders := make(map[string]Folder) // map of old path : Folder
+	if index < 0 || index == len(siblings) {
+		nameFolder.Position = 0
+		if len(siblings) > 0 {
+			nameFolder.Position = siblings[len(siblings) - 1].Position + 1
+		}
+	} else {
+		for n, sibling := range siblings {
+			position := n
+			if n >= index {
+				position++
+			}
+			if sibling.Position != position {
+				sibling.Position = position
+				updatingFolders[sibling.Paths] = sibling
+			}
+		}
+		nameFolder.Position = index
 	}
 
-	for i := range folders {
-		if _, exists := updatingFolders[folders[i].Name]; exists {
-			folders[i].Paths = updatingFolders[folders[i].Name].Paths
+	nameFolder.Paths = newNamePath
+	updatingFolders[oldNamePath] = nameFolder
+	if newNamePath != oldNamePath {
+		for _, f := range childFolders {
+			oldPath := f.Paths
+			f.Paths = newNamePath + "." + f.Paths[orgNamePathLen + 1:] // (+1) due to extra '.'
+			updatingFolders[oldPath] = f
 		}
 	}
+	f.updateFolders(idx, updatingFolders)
+
+	return nil
+}
+
+/* Swaps folders, by their old path, for updated records and patches the index of their org */
+func (f *driver) updateFolders(idx *orgIndex, updatingFolders map[string]Folder) []Folder {
+	records := idx.records(updatingFolders)
+	if len(records) == 0 {
+		return []Folder{}
+	}
+	updated := make([]Folder, len(records))
+	for i, record := range records {
+		updated[i] = record.folder
+	}
+	f.writeFolders(records[0].folder.OrgId, records, nil)
 
-	return folders, nil
+	return f.refresh(records[0].folder.OrgId, updated)
+}
+
+/* Pairs updated folders, by their old path, with their sequence, in the org's order */
+func (idx *orgIndex) records(updatingFolders map[string]Folder) []folderRecord {
+	records := make([]folderRecord, 0, len(updatingFolders))
+	for oldPath, folder := range updatingFolders {
+		seq, _ := idx.byPath.Get(oldPath)
+		records = append(records, folderRecord{folder, seq})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].seq < records[j].seq
+	})
+	return records
 }
@@ -127,7 +127,510 @@ func Test_folder_MoveFolder(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+}
+
+func Test_folder_MoveFolder_SharedNames(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder{
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+		{Name: "A", OrgId: otherOrgID, Paths: "A"},
+		{Name: "B", OrgId: otherOrgID, Paths: "B", Position: 1},
+	}
+
+	f := folder.NewDriver(folders)
+	get, err := f.MoveFolder("A", "B")
+	assert.EqualError(t, err, folder.ErrAmbiguousSource)
+	assert.Equal(t, []folder.Folder{}, get)
+}
+
+func Test_folder_MoveFolderInOrg(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	tests := [...]struct {
+		name string
+		folders []folder.Folder
+		orgID uuid.UUID
+		sourceName string
+		destinationName string
+		wantFolders []folder.Folder
+		wantError error
+	} {
+		{
+			name: "Move within org when other org shares names",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+				{Name: "A", OrgId: otherOrgID, Paths: "A"},
+				{Name: "B", OrgId: otherOrgID, Paths: "B", Position: 1},
+			},
+			orgID: otherOrgID,
+			sourceName: "A",
+			destinationName: "B",
+			wantFolders: []folder.Folder {
+				{Id: folder.DeriveFolderID(otherOrgID, "A"), Name: "A", OrgId: otherOrgID, Paths: "B.A", Version: 1},
+				{Name: "B", OrgId: otherOrgID, Paths: "B", Position: 1},
+			},
+			wantError: nil,
+		},
+		{
+			name: "Destination only in another org",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: otherOrgID, Paths: "B"},
+			},
+			orgID: orgID,
+			sourceName: "A",
+			destinationName: "B",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrDestNotExist),
+		},
+		{
+			name: "Source only in another org",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: otherOrgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "B"},
+			},
+			orgID: orgID,
+			sourceName: "A",
+			destinationName: "B",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceNotExists),
+		},
+		{
+			name: "Ambiguous source in org",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "C", OrgId: orgID, Paths: "A.C"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+				{Name: "C", OrgId: orgID, Paths: "B.C"},
+				{Name: "D", OrgId: orgID, Paths: "D", Position: 2},
+			},
+			orgID: orgID,
+			sourceName: "C",
+			destinationName: "D",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrAmbiguousSource),
+		},
+		{
+			name: "Ambiguous destination in org",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "C", OrgId: orgID, Paths: "A.C"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+				{Name: "C", OrgId: orgID, Paths: "B.C"},
+				{Name: "D", OrgId: orgID, Paths: "D", Position: 2},
+			},
+			orgID: orgID,
+			sourceName: "D",
+			destinationName: "C",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrAmbiguousDest),
+		},
+		{
+			name: "Invalid nil orgID",
+			folders: []folder.Folder{},
+			orgID: uuid.Nil,
+			sourceName: "A",
+			destinationName: "B",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrInvalidOrgID),
+		},
+		{
+			name: "Unknown orgID",
+			folders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+			},
+			orgID: otherOrgID,
+			sourceName: "A",
+			destinationName: "B",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrFolderNotExistsOrg),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(tt.folders)
+			get, err := f.MoveFolderInOrg(tt.orgID, tt.sourceName, tt.destinationName)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+}
+
+func Test_folder_MoveFolderByPath(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "reports", OrgId: orgID, Paths: "A.reports"},
+		{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+		{Name: "reports", OrgId: orgID, Paths: "B.reports"},
+		{Name: "C", OrgId: orgID, Paths: "B.reports.C"},
+		{Name: "D", OrgId: orgID, Paths: "D", Position: 2},
+	}
+	tests := [...]struct {
+		name string
+		sourcePath string
+		destinationPath string
+		wantFolders []folder.Folder
+		wantError error
+	} {
+		{
+			name: "Move one of two folders sharing a name",
+			sourcePath: "B.reports",
+			destinationPath: "D",
+			wantFolders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "reports", OrgId: orgID, Paths: "A.reports"},
+				{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+				{Id: folder.DeriveFolderID(orgID, "B.reports"), Name: "reports", OrgId: orgID, Paths: "D.reports", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "B.reports.C"), Name: "C", OrgId: orgID, Paths: "D.reports.C", Version: 1},
+				{Name: "D", OrgId: orgID, Paths: "D", Position: 2},
+			},
+		},
+		{
+			name: "Destination already holds the name",
+			sourcePath: "B.reports",
+			destinationPath: "A",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrFolderNameConflict + " A.reports"),
+		},
+		{
+			name: "Move into own subtree",
+			sourcePath: "B",
+			destinationPath: "B.reports.C",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceToChild),
+		},
+		{
+			name: "Same source and destination",
+			sourcePath: "B",
+			destinationPath: "B",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceToItself),
+		},
+		{
+			name: "Non-existent source path",
+			sourcePath: "C",
+			destinationPath: "D",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceNotExists),
+		},
+		{
+			name: "Non-existent destination path",
+			sourcePath: "B",
+			destinationPath: "A.C",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrDestNotExist),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.MoveFolderByPath(orgID, tt.sourcePath, tt.destinationPath)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+}
+
+func Test_folder_MoveFolderByID(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	idA, idB, idC, idD := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+	folders := []folder.Folder {
+		{Id: idA, Name: "A", OrgId: orgID, Paths: "A"},
+		{Id: idB, Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Id: idC, Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+		{Id: idD, Name: "D", OrgId: otherOrgID, Paths: "D"},
+	}
+	tests := [...]struct {
+		name string
+		source uuid.UUID
+		destination uuid.UUID
+		wantFolders []folder.Folder
+		wantError error
+	} {
+		{
+			name: "IDs survive the move",
+			source: idB,
+			destination: idC,
+			wantFolders: []folder.Folder {
+				{Id: idA, Name: "A", OrgId: orgID, Paths: "A"},
+				{Id: idB, Name: "B", OrgId: orgID, Paths: "C.B", Version: 1},
+				{Id: idC, Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+			},
+		},
+		{
+			name: "Move to a different organisation",
+			source: idA,
+			destination: idD,
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrFolderToDiffOrg),
+		},
+		{
+			name: "Move into own subtree",
+			source: idA,
+			destination: idB,
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceToChild),
+		},
+		{
+			name: "Unknown source ID",
+			source: uuid.Must(uuid.NewV4()),
+			destination: idC,
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceNotExists),
+		},
+		{
+			name: "Unknown destination ID",
+			source: idA,
+			destination: uuid.Must(uuid.NewV4()),
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrDestNotExist),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.MoveFolderByID(tt.source, tt.destination)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
 			assert.Equal(t, tt.wantFolders, get)
 		})
 	}
 }
+
+func Test_folder_MoveFolderPositional(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.C", Position: 1},
+		{Name: "D", OrgId: orgID, Paths: "A.D", Position: 2},
+		{Name: "E", OrgId: orgID, Paths: "E", Position: 1},
+		{Name: "F", OrgId: orgID, Paths: "E.F"},
+	})
+	tests := [...]struct {
+		name string
+		move func(f folder.IDriver) ([]folder.Folder, error)
+		wantChildren []string // paths of A's children, in order
+		wantError error
+	} {
+		{
+			name: "Move to the front of another parent",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "E.F", "A", 0)
+			},
+			wantChildren: []string{"A.F", "A.B", "A.C", "A.D"},
+		},
+		{
+			name: "Move into the middle of another parent",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "E.F", "A", 2)
+			},
+			wantChildren: []string{"A.B", "A.C", "A.F", "A.D"},
+		},
+		{
+			name: "Move to the end of another parent",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "E.F", "A", 3)
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D", "A.F"},
+		},
+		{
+			name: "Reorder within the same parent",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "A.D", "A", 0)
+			},
+			wantChildren: []string{"A.D", "A.B", "A.C"},
+		},
+		{
+			name: "Move before a sibling",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderBefore(orgID, "A.D", "A.C")
+			},
+			wantChildren: []string{"A.B", "A.D", "A.C"},
+		},
+		{
+			name: "Move after a sibling",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderAfter(orgID, "A.B", "A.C")
+			},
+			wantChildren: []string{"A.C", "A.B", "A.D"},
+		},
+		{
+			name: "Move after a sibling under another parent",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderAfter(orgID, "E.F", "A.B")
+			},
+			wantChildren: []string{"A.B", "A.F", "A.C", "A.D"},
+		},
+		{
+			name: "Plain move goes last",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderByPath(orgID, "E.F", "A")
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D", "A.F"},
+		},
+		{
+			name: "Index out of range",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "E.F", "A", 4)
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D"},
+			wantError: errors.New(folder.ErrInvalidPosition),
+		},
+		{
+			name: "Negative index",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderToIndex(orgID, "E.F", "A", -1)
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D"},
+			wantError: errors.New(folder.ErrInvalidPosition),
+		},
+		{
+			name: "Before itself",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderBefore(orgID, "A.B", "A.B")
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D"},
+			wantError: errors.New(folder.ErrSourceToItself),
+		},
+		{
+			name: "Next to a folder in its own subtree",
+			move: func(f folder.IDriver) ([]folder.Folder, error) {
+				return f.MoveFolderBefore(orgID, "A", "A.B")
+			},
+			wantChildren: []string{"A.B", "A.C", "A.D"},
+			wantError: errors.New(folder.ErrSourceToChild),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			_, err := tt.move(f)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			children, err := f.GetChildFolders(orgID, "A", folder.ChildQueryOptions{MaxDepth: 1})
+			assert.NoError(t, err)
+			paths := []string{}
+			for _, c := range children {
+				paths = append(paths, c.Paths)
+			}
+			assert.Equal(t, tt.wantChildren, paths)
+		})
+	}
+}
+
+func Test_folder_MoveFolderToRoot(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "A.D", Position: 1},
+		{Name: "D", OrgId: orgID, Paths: "D", Position: 1},
+		{Name: "B", OrgId: otherOrgID, Paths: "B"},
+	}
+	tests := [...]struct {
+		name string
+		sourcePath string
+		wantFolders []folder.Folder
+		wantError error
+	} {
+		{
+			name: "Nested folder becomes a root with its subtree",
+			sourcePath: "A.B",
+			wantFolders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Id: folder.DeriveFolderID(orgID, "A.B"), Name: "B", OrgId: orgID, Paths: "B", Position: 2, Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "B.C", Version: 1},
+				{Name: "D", OrgId: orgID, Paths: "A.D", Position: 1},
+				{Name: "D", OrgId: orgID, Paths: "D", Position: 1},
+			},
+		},
+		{
+			name: "Root already exists with that name",
+			sourcePath: "A.D",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrFolderNameConflict + " D"),
+		},
+		{
+			name: "Root stays where it is",
+			sourcePath: "A",
+			wantFolders: []folder.Folder {
+				{Name: "A", OrgId: orgID, Paths: "A"},
+				{Name: "B", OrgId: orgID, Paths: "A.B"},
+				{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+				{Name: "D", OrgId: orgID, Paths: "A.D", Position: 1},
+				{Name: "D", OrgId: orgID, Paths: "D", Position: 1},
+			},
+		},
+		{
+			name: "Non-existent source",
+			sourcePath: "A.E",
+			wantFolders: []folder.Folder{},
+			wantError: errors.New(folder.ErrSourceNotExists),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.MoveFolderToRoot(orgID, tt.sourcePath)
+			if tt.wantError != nil {
+				assert.EqualError(t, err, tt.wantError.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, withIDs(tt.wantFolders), get)
+		})
+	}
+
+	// the new root is found like any other
+	f := folder.NewDriver(folders)
+	_, err := f.MoveFolderToRoot(orgID, "A.B")
+	assert.NoError(t, err)
+	children, err := f.GetAllChildFoldersByPath(orgID, "B")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder {
+		{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "B.C", Version: 1},
+	}, children)
+	_, err = f.GetParent(orgID, "B")
+	assert.EqualError(t, err, folder.ErrFolderIsRoot)
+}
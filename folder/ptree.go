@@ -0,0 +1,180 @@
+package folder
+
+/*
+ptree is a sorted map that is never modified once built, a weight-balanced
+binary tree. Put and Delete return a new tree that shares every node off the
+path they change with the old one, so a change costs O(log n) and any number
+of older trees stay valid next to it. That is what lets an org's index be
+patched rather than rebuilt while snapshots and transactions hold on to
+earlier versions of it.
+*/
+type ptree[K any, V any] struct {
+	root *pnode[K, V]
+	cmp  func(a, b K) int
+}
+
+type pnode[K any, V any] struct {
+	key         K
+	value       V
+	size        int
+	left, right *pnode[K, V]
+}
+
+// balance factors of the tree, as in Adams' weight-balanced trees
+const (
+	ptreeDelta = 3
+	ptreeRatio = 2
+)
+
+/* Builds a tree from keys that are sorted and unique, in O(n) */
+func sortedPtree[K any, V any](cmp func(a, b K) int, keys []K, values []V) ptree[K, V] {
+	var build func(lo, hi int) *pnode[K, V]
+	build = func(lo, hi int) *pnode[K, V] {
+		if lo >= hi {
+			return nil
+		}
+		mid := (lo + hi) / 2
+		return newPnode(keys[mid], values[mid], build(lo, mid), build(mid+1, hi))
+	}
+	return ptree[K, V]{root: build(0, len(keys)), cmp: cmp}
+}
+
+func (t ptree[K, V]) Len() int {
+	return t.root.len()
+}
+
+func (t ptree[K, V]) Get(key K) (V, bool) {
+	n := t.root
+	for n != nil {
+		c := t.cmp(key, n.key)
+		switch {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return n.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (t ptree[K, V]) Put(key K, value V) ptree[K, V] {
+	t.root = t.put(t.root, key, value)
+	return t
+}
+
+func (t ptree[K, V]) Delete(key K) ptree[K, V] {
+	t.root = t.delete(t.root, key)
+	return t
+}
+
+// Ascend calls fn for every entry in key order until it returns false.
+func (t ptree[K, V]) Ascend(fn func(key K, value V) bool) {
+	t.root.ascend(fn)
+}
+
+func (t ptree[K, V]) put(n *pnode[K, V], key K, value V) *pnode[K, V] {
+	if n == nil {
+		return newPnode(key, value, nil, nil)
+	}
+	c := t.cmp(key, n.key)
+	switch {
+	case c < 0:
+		return balance(n.key, n.value, t.put(n.left, key, value), n.right)
+	case c > 0:
+		return balance(n.key, n.value, n.left, t.put(n.right, key, value))
+	}
+	return newPnode(key, value, n.left, n.right)
+}
+
+func (t ptree[K, V]) delete(n *pnode[K, V], key K) *pnode[K, V] {
+	if n == nil {
+		return nil
+	}
+	c := t.cmp(key, n.key)
+	switch {
+	case c < 0:
+		if left := t.delete(n.left, key); left != n.left {
+			return balance(n.key, n.value, left, n.right)
+		}
+		return n
+	case c > 0:
+		if right := t.delete(n.right, key); right != n.right {
+			return balance(n.key, n.value, n.left, right)
+		}
+		return n
+	}
+	return glue(n.left, n.right)
+}
+
+func newPnode[K any, V any](key K, value V, left, right *pnode[K, V]) *pnode[K, V] {
+	return &pnode[K, V]{key: key, value: value, size: left.len() + right.len() + 1, left: left, right: right}
+}
+
+func (n *pnode[K, V]) len() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *pnode[K, V]) ascend(fn func(key K, value V) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.ascend(fn) && fn(n.key, n.value) && n.right.ascend(fn)
+}
+
+/* Joins two subtrees whose keys all sort left before right */
+func glue[K any, V any](left, right *pnode[K, V]) *pnode[K, V] {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.size > right.size:
+		key, value, rest := deleteMax(left)
+		return balance(key, value, rest, right)
+	}
+	key, value, rest := deleteMin(right)
+	return balance(key, value, left, rest)
+}
+
+func deleteMin[K any, V any](n *pnode[K, V]) (K, V, *pnode[K, V]) {
+	if n.left == nil {
+		return n.key, n.value, n.right
+	}
+	key, value, left := deleteMin(n.left)
+	return key, value, balance(n.key, n.value, left, n.right)
+}
+
+func deleteMax[K any, V any](n *pnode[K, V]) (K, V, *pnode[K, V]) {
+	if n.right == nil {
+		return n.key, n.value, n.left
+	}
+	key, value, right := deleteMax(n.right)
+	return key, value, balance(n.key, n.value, n.left, right)
+}
+
+/* Builds a node whose subtrees were balanced until one of them changed by a single entry */
+func balance[K any, V any](key K, value V, left, right *pnode[K, V]) *pnode[K, V] {
+	sl, sr := left.len(), right.len()
+	switch {
+	case sl+sr <= 1:
+	case sr > ptreeDelta*sl:
+		if right.left.len() < ptreeRatio*right.right.len() {
+			return newPnode(right.key, right.value, newPnode(key, value, left, right.left), right.right)
+		}
+		rl := right.left
+		return newPnode(rl.key, rl.value, newPnode(key, value, left, rl.left), newPnode(right.key, right.value, rl.right, right.right))
+	case sl > ptreeDelta*sr:
+		if left.right.len() < ptreeRatio*left.left.len() {
+			return newPnode(left.key, left.value, left.left, newPnode(key, value, left.right, right))
+		}
+		lr := left.right
+		return newPnode(lr.key, lr.value, newPnode(left.key, left.value, left.left, lr.left), newPnode(key, value, lr.right, right))
+	}
+	return newPnode(key, value, left, right)
+}
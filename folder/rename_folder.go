@@ -0,0 +1,57 @@
+package folder
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+func (f *driver) RenameFolder(orgID uuid.UUID, path string, newName string, opts ...WriteOption) (_ []Folder, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("RenameFolder", &err)
+
+	if !ValidateFolderName(newName) {
+		return []Folder{}, errors.New(ErrInvalidFolderName + " " + newName)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return []Folder{}, err
+	}
+	target, err := idx.resolvePath(path, ErrFolderNotExist)
+	if err != nil {
+		return []Folder{}, err
+	}
+	if err := checkVersion(target, opts); err != nil {
+		return []Folder{}, err
+	}
+	if target.Name == newName {
+		return []Folder{}, nil // nothing to change
+	}
+
+	newPath := newName
+	if parent := parentPath(target.Paths); parent != "" {
+		newPath = parent + "." + newName
+	}
+	if _, exists := idx.byPath.Get(newPath); exists {
+		return []Folder{}, errors.New(ErrFolderNameConflict + " " + newPath)
+	}
+
+	childFolders, err := idx.descendants(target.Paths)
+	if err != nil {
+		return []Folder{}, err
+	}
+
+	updatingFolders := make(map[string]Folder) // map of old path : Folder
+	target.Name = newName
+	target.Paths = newPath
+	updatingFolders[path] = target
+	for _, f := range childFolders {
+		oldPath := f.Paths
+		f.Paths = newPath + f.Paths[len(path):] // keeps the leading '.'
+		updatingFolders[oldPath] = f
+	}
+
+	return f.updateFolders(idx, updatingFolders), nil
+}
@@ -0,0 +1,122 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_RenameFolder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "B", OrgId: orgID, Paths: "A.B.C.B"},
+		{Name: "D", OrgId: orgID, Paths: "A.D", Position: 1},
+		{Name: "AB", OrgId: orgID, Paths: "AB", Position: 1},
+	}
+	tests := [...]struct {
+		name string
+		path string
+		newName string
+		wantFolders []folder.Folder
+		wantErr error
+	} {
+		{
+			name: "Rename folder and its subtree",
+			path: "A.B",
+			newName: "X",
+			wantFolders: []folder.Folder {
+				{Id: folder.DeriveFolderID(orgID, "A.B"), Name: "X", OrgId: orgID, Paths: "A.X", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "A.X.C", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C.B"), Name: "B", OrgId: orgID, Paths: "A.X.C.B", Version: 1},
+			},
+		},
+		{
+			name: "Rename root leaves similar prefixes alone",
+			path: "A",
+			newName: "Z",
+			wantFolders: []folder.Folder {
+				{Id: folder.DeriveFolderID(orgID, "A"), Name: "Z", OrgId: orgID, Paths: "Z", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B"), Name: "B", OrgId: orgID, Paths: "Z.B", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C"), Name: "C", OrgId: orgID, Paths: "Z.B.C", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.B.C.B"), Name: "B", OrgId: orgID, Paths: "Z.B.C.B", Version: 1},
+				{Id: folder.DeriveFolderID(orgID, "A.D"), Name: "D", OrgId: orgID, Paths: "Z.D", Position: 1, Version: 1},
+			},
+		},
+		{
+			name: "Same name changes nothing",
+			path: "A.B",
+			newName: "B",
+			wantFolders: []folder.Folder{},
+		},
+		{
+			name: "Sibling conflict",
+			path: "A.B",
+			newName: "D",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrFolderNameConflict + " A.D"),
+		},
+		{
+			name: "Root conflict",
+			path: "A",
+			newName: "AB",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrFolderNameConflict + " AB"),
+		},
+		{
+			name: "Invalid name",
+			path: "A.B",
+			newName: "X.Y",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrInvalidFolderName + " X.Y"),
+		},
+		{
+			name: "Non-existent folder",
+			path: "A.E",
+			newName: "X",
+			wantFolders: []folder.Folder{},
+			wantErr: errors.New(folder.ErrFolderNotExist),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := folder.NewDriver(folders)
+			get, err := f.RenameFolder(orgID, tt.path, tt.newName)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				assert.Equal(t, withIDs(folders), f.GetFoldersByOrgID(orgID))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantFolders, get)
+		})
+	}
+}
+
+func Test_folder_RenameFolderThenQuery(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+	})
+
+	_, err := f.RenameFolder(orgID, "A", "Z")
+	assert.NoError(t, err)
+
+	children, err := f.GetAllChildFoldersByPath(orgID, "Z")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.Folder{
+		{Id: folder.DeriveFolderID(orgID, "A.B"), Name: "B", OrgId: orgID, Paths: "Z.B", Version: 1},
+	}, children)
+
+	_, err = f.GetAllChildFolders(orgID, "A")
+	assert.EqualError(t, err, folder.ErrFolderNotExist)
+}
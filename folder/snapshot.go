@@ -0,0 +1,66 @@
+package folder
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+/*
+Snapshot is the state of a driver at one point in time. Changes never modify
+an org's index, they build a new one and swap it in, so a snapshot only has to
+hold on to the indexes that were current when it was taken. Taking one costs a
+map entry per organisation and unchanged orgs are shared with the driver and
+other snapshots.
+*/
+type Snapshot struct {
+	orgs map[uuid.UUID]*orgIndex
+}
+
+func (f *driver) Snapshot() *Snapshot {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.snapshot()
+}
+
+func (f *driver) snapshot() *Snapshot {
+	orgs := make(map[uuid.UUID]*orgIndex, len(f.orgs))
+	for orgID, idx := range f.orgs {
+		orgs[orgID] = idx
+	}
+	return &Snapshot{orgs: orgs}
+}
+
+/* A driver over the snapshot's orgs, for read methods that only need those */
+func (s *Snapshot) view() *driver {
+	return &driver{orgs: s.orgs}
+}
+
+// Folders returns every folder in the snapshot, in the order they were added.
+func (s *Snapshot) Folders() []Folder {
+	return s.view().allFolders()
+}
+
+// GetFoldersByOrgID returns the folders of an organisation.
+func (s *Snapshot) GetFoldersByOrgID(orgID uuid.UUID) []Folder {
+	return s.view().GetFoldersByOrgID(orgID)
+}
+
+// GetAllChildFoldersByPath returns all child folders of the folder at path.
+func (s *Snapshot) GetAllChildFoldersByPath(orgID uuid.UUID, path string) ([]Folder, error) {
+	return s.view().GetAllChildFoldersByPath(orgID, path)
+}
+
+// GetFolderByID returns the folder with the given ID.
+func (s *Snapshot) GetFolderByID(id uuid.UUID) (Folder, error) {
+	for _, idx := range s.orgs {
+		if seq, exists := idx.byID.Get(id); exists {
+			if idx.errs[seq] != nil {
+				return Folder{}, idx.errs[seq]
+			}
+			return idx.folder(seq), nil
+		}
+	}
+	return Folder{}, errors.New(ErrFolderNotExist)
+}
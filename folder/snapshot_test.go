@@ -0,0 +1,74 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_CallerSliceUntouched(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := []folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "C"},
+	}
+	original := append([]folder.Folder{}, folders...)
+	f := folder.NewDriver(folders)
+
+	_, err := f.MoveFolder("B", "C")
+	assert.NoError(t, err)
+	assert.Equal(t, original, folders)
+
+	// the second call works on the moved data, not on the caller's
+	res, err := f.MoveFolder("B", "A")
+	assert.NoError(t, err)
+	back := withIDs(original)[1]
+	back.Version = 2
+	assert.Equal(t, back, res[1])
+	assert.Equal(t, original, folders)
+}
+
+func Test_folder_Snapshot(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+		{Name: "X", OrgId: otherOrgID, Paths: "X"},
+	})
+	f := folder.NewDriver(folders)
+	before := f.Snapshot()
+
+	_, err := f.MoveFolder("B", "C")
+	assert.NoError(t, err)
+	_, err = f.DeleteFolder(otherOrgID, "X", folder.DeleteIfEmpty)
+	assert.NoError(t, err)
+	after := f.Snapshot()
+	_, err = f.RenameFolder(orgID, "C", "D")
+	assert.NoError(t, err)
+
+	// each snapshot still shows the state it was taken in
+	assert.Equal(t, folders, before.Folders())
+	assert.Equal(t, folders[3:], before.GetFoldersByOrgID(otherOrgID))
+	child, err := before.GetAllChildFoldersByPath(orgID, "A")
+	assert.NoError(t, err)
+	assert.Equal(t, folders[1:2], child)
+
+	moved := folder.Folder{Id: folders[1].Id, Name: "B", OrgId: orgID, Paths: "C.B", Version: 1}
+	assert.Equal(t, []folder.Folder{folders[0], moved, folders[2]}, after.Folders())
+	assert.Equal(t, []folder.Folder{}, after.GetFoldersByOrgID(otherOrgID))
+	got, err := after.GetFolderByID(folders[1].Id)
+	assert.NoError(t, err)
+	assert.Equal(t, moved, got)
+	_, err = after.GetFolderByID(folders[3].Id)
+	assert.Equal(t, errors.New(folder.ErrFolderNotExist), err)
+
+	_, err = after.GetAllChildFoldersByPath(orgID, "D")
+	assert.Equal(t, errors.New(folder.ErrFolderNotExist), err)
+}
@@ -3,9 +3,6 @@ package folder
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"io/fs"
-	"os"
 	"path/filepath"
 	"runtime"
 	"github.com/gofrs/uuid"
@@ -29,9 +26,34 @@ const MaxDepth = 5
 const DefaultOrgID = "c1556e17-b7c0-45a3-a6ae-9546248fb17a"
 
 type Folder struct {
+	Id    uuid.UUID `json:"id"`
 	Name  string    `json:"name"`
 	OrgId uuid.UUID `json:"org_id"`
 	Paths string    `json:"paths"`
+	// Position orders a folder among its siblings, lower comes first
+	Position int `json:"position"`
+	// Version goes up by one every time the folder changes
+	Version int `json:"version"`
+}
+
+// DeriveFolderID returns the ID given to a folder that was loaded without one.
+// It is derived from the org and path so loading the same file twice yields the same IDs.
+func DeriveFolderID(orgID uuid.UUID, path string) uuid.UUID {
+	return uuid.NewV5(orgID, path)
+}
+
+/* Gives every folder without an ID one, and a fresh one to any folder repeating an earlier ID */
+func assignMissingIDs(folders []Folder) {
+	seen := make(map[uuid.UUID]bool, len(folders))
+	for i := range folders {
+		if folders[i].Id.IsNil() {
+			folders[i].Id = DeriveFolderID(folders[i].OrgId, folders[i].Paths)
+		}
+		if seen[folders[i].Id] {
+			folders[i].Id = uuid.Must(uuid.NewV4())
+		}
+		seen[folders[i].Id] = true
+	}
 }
 
 func GenerateData() []Folder {
@@ -102,74 +124,33 @@ func PrettyPrint(b interface{}) {
 	fmt.Print(string(s))
 }
 
+// GetSampleData returns the folders in sample.json.
 func GetSampleData() []Folder {
-	_, filename, _, _ := runtime.Caller(0)
-	fmt.Println(filename)
-	basePath := filepath.Dir(filename)
-	filePath := filepath.Join(basePath, "sample.json")
-
-	fmt.Println(filePath)
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
-	jsonByte, err := io.ReadAll(file)
-	if err != nil {
-		panic(err)
-	}
-
-	folders := []Folder{}
-	err = json.Unmarshal(jsonByte, &folders)
-	if err != nil {
-		panic(err)
-	}
-
-	return folders
+	return mustLoadSample("sample.json")
 }
 
-func WriteSampleData(data interface{}) {
-	b := MarshalJson(data)
+/*
+WriteSampleData replaces sample.json next to this source file with data, for
+regenerating the sample. The binary has to be run from a checkout for that.
+*/
+func WriteSampleData(data interface{}) error {
 	_, filename, _, _ := runtime.Caller(0)
-	fmt.Println(filename)
-	basePath := filepath.Dir(filename)
-	filePath := filepath.Join(basePath, "sample.json")
+	filePath := filepath.Join(filepath.Dir(filename), "sample.json")
 
-	fmt.Println(filePath)
-
-	err := os.WriteFile(filePath, b, fs.ModeType)
-	if err != nil {
-		panic(err)
-	}
+	return writeFileAtomic(filePath, MarshalJson(data), FilePerm)
 }
 
+// GetSampleDefaultOrgIDOnlyData returns the folders in sample_only_defaultOrgID.json.
 func GetSampleDefaultOrgIDOnlyData() []Folder {
-	_, filename, _, _ := runtime.Caller(0)
-	fmt.Println(filename)
-	basePath := filepath.Dir(filename)
-	filePath := filepath.Join(basePath, "sample_only_defaultOrgID.json")
-
-	fmt.Println(filePath)
-
-	file, err := os.Open(filePath)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
-	jsonByte, err := io.ReadAll(file)
-	if err != nil {
-		panic(err)
-	}
+	return mustLoadSample("sample_only_defaultOrgID.json")
+}
 
-	folders := []Folder{}
-	err = json.Unmarshal(jsonByte, &folders)
+/* The samples are compiled in and loaded by the tests, so this can't fail at run time */
+func mustLoadSample(name string) []Folder {
+	folders, err := LoadFoldersFS(sampleFS, name)
 	if err != nil {
 		panic(err)
 	}
-
 	return folders
 }
 
@@ -183,6 +164,10 @@ const ErrInvalidOrgID = "Error: Invalid orgID"
 const ErrFolderNotExistsOrg = "Error: Folder does not exist in the specified organization"
 const ErrFolderNotMatchPathEnd = "Error: Folder name doesn't match end of path"
 const ErrFolderNotExist = "Error: Folder does not exist"
+const ErrDuplicatePath = "Error: path is used by more than one folder"
+const ErrFolderIsRoot = "Error: Folder has no parent"
+const ErrInvalidMaxDepth = "Error: max depth can't be negative"
+const ErrUnknownChildOrder = "Error: unknown child order"
 
 // move folder error messages
 
@@ -190,4 +175,71 @@ const ErrSourceToItself = "Error: Cannot move a folder to itself"
 const ErrSourceNotExists = "Error: source folder does not exist"
 const ErrDestNotExist = "Error: destination folder does not exist"
 const ErrFolderToDiffOrg = "Error: cannot move a folder to a different organization"
-const ErrSourceToChild = "Error: cannot move a folder to a child of itself"
\ No newline at end of file
+const ErrSourceToChild = "Error: cannot move a folder to a child of itself"
+const ErrAmbiguousSource = "Error: source folder name matches more than one folder"
+const ErrAmbiguousDest = "Error: destination folder name matches more than one folder"
+const ErrFolderNameConflict = "Error: a folder already exists at"
+const ErrInvalidPosition = "Error: position is out of range"
+
+// create folder error messages
+
+const ErrInvalidFolderName = "Error: invalid folder name"
+
+// delete folder error messages
+
+const ErrFolderNotEmpty = "Error: folder has child folders"
+const ErrUnknownDeletePolicy = "Error: unknown delete policy"
+
+// copy folder error messages
+
+const ErrUnknownConflictStrategy = "Error: unknown conflict strategy"
+
+// transfer folder error messages
+
+const ErrTransferSameOrg = "Error: folder is already in that organisation"
+
+// batch error messages
+
+const ErrUnknownOperation = "Error: Unknown operation kind"
+
+const ErrBatchAborted = "Error: operation skipped, an earlier operation in the batch failed"
+
+// transaction error messages
+
+const ErrTxDone = "Error: transaction has already been committed or rolled back"
+
+const ErrTxConflict = "Error: driver has changed since the transaction began"
+
+// history error messages
+
+const ErrNothingToUndo = "Error: there is no change to undo"
+
+const ErrNothingToRedo = "Error: there is no change to redo"
+
+// subscription error messages
+
+const ErrSubscriberLagged = "Error: subscriber fell behind and was dropped"
+
+// version error messages
+
+const ErrVersionConflict = "Error: folder has changed since it was read"
+
+// journal error messages
+
+const ErrJournalCorrupt = "Error: journal is corrupt at byte"
+
+const ErrCompactFailed = "Error: change was saved but the journal couldn't be compacted"
+
+// loader error messages
+
+const ErrFolderInWrongOrgFile = "Error: folder belongs to a different organisation than its file"
+
+// import error messages
+
+const ErrMalformedRecord = "Error: malformed folder record"
+
+const ErrNotFolderArray = "Error: input is not a JSON array of folders"
+
+const ErrRecordNotObject = "Error: record is not a JSON object"
+
+const ErrTrailingData = "Error: unexpected data after the folder array"
\ No newline at end of file
@@ -0,0 +1,64 @@
+package folder
+
+import "sync"
+
+/*
+Store is where a driver opened with Open keeps its folders. A driver saves
+every change before the call making it returns, and undoes the change if Save
+fails, so what a store holds never runs behind what callers were told. A
+CompactError is the exception, the change was saved, so the call keeps it and
+returns the error along with its result.
+
+MemoryStore, FileStore and JournalStore trade durability for speed in that
+order, callers don't have to change to switch between them.
+*/
+type Store interface {
+	// Load returns the folders saved so far, none for a new store.
+	Load() ([]Folder, error)
+	// Save makes a change durable. event lists the folders it changed and
+	// state holds every folder after it, a store can use either.
+	Save(event ChangeEvent, state *Snapshot) error
+}
+
+// Open returns a driver over the folders in store, saving every change to it.
+func Open(store Store, opts ...Option) (IDriver, error) {
+	folders, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	f := NewDriver(folders, opts...).(*driver)
+	f.store = store
+	return f, nil
+}
+
+// MemoryStore keeps folders in memory only, they live as long as the store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	folders []Folder
+	state   *Snapshot // latest saved state, nil until the first save
+}
+
+// NewMemoryStore returns a store that starts out with a copy of folders.
+func NewMemoryStore(folders []Folder) *MemoryStore {
+	return &MemoryStore{folders: append([]Folder{}, folders...)}
+}
+
+func (s *MemoryStore) Load() ([]Folder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != nil {
+		return s.state.Folders(), nil
+	}
+	return append([]Folder{}, s.folders...), nil
+}
+
+// Save keeps hold of state, snapshots are never modified so nothing is copied.
+func (s *MemoryStore) Save(event ChangeEvent, state *Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = state
+	return nil
+}
@@ -0,0 +1,165 @@
+package folder_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+Every store is put through the same tests. reopen returns a new Store over
+the same backing data, which is what a restarted process would get.
+*/
+var stores = [...]struct {
+	name string
+	backing func(t *testing.T) (reopen func() folder.Store)
+} {
+	{
+		name: "Memory",
+		backing: func(t *testing.T) func() folder.Store {
+			store := folder.NewMemoryStore(nil)
+			return func() folder.Store { return store }
+		},
+	},
+	{
+		name: "File",
+		backing: func(t *testing.T) func() folder.Store {
+			path := filepath.Join(t.TempDir(), "folders.json")
+			return func() folder.Store { return folder.NewFileStore(path) }
+		},
+	},
+	{
+		name: "Journal",
+		backing: func(t *testing.T) func() folder.Store {
+			dir := t.TempDir()
+			return func() folder.Store { return folder.NewJournalStore(dir, 4) }
+		},
+	},
+}
+
+/* Fails every save once failing is set */
+type failingStore struct {
+	folder.Store
+	failing bool
+}
+
+func (s *failingStore) Save(event folder.ChangeEvent, state *folder.Snapshot) error {
+	if s.failing {
+		return errors.New("disk full")
+	}
+	return s.Store.Save(event, state)
+}
+
+func folderPaths(folders []folder.Folder) []string {
+	res := []string{}
+	for _, f := range folders {
+		res = append(res, f.Paths)
+	}
+	return res
+}
+
+func Test_folder_Store(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("Starts empty", func(t *testing.T) {
+				t.Parallel()
+				f, err := folder.Open(st.backing(t)())
+				assert.NoError(t, err)
+				assert.Empty(t, f.Snapshot().Folders())
+			})
+
+			t.Run("Reopen sees every change", func(t *testing.T) {
+				t.Parallel()
+				reopen := st.backing(t)
+				f, err := folder.Open(reopen())
+				assert.NoError(t, err)
+				journalChanges(t, f, orgID, otherOrgID)
+
+				reopened, err := folder.Open(reopen())
+				assert.NoError(t, err)
+				assert.Equal(t, f.Snapshot().Folders(), reopened.Snapshot().Folders())
+			})
+
+			t.Run("Batches and transactions are saved once committed", func(t *testing.T) {
+				t.Parallel()
+				reopen := st.backing(t)
+				f, err := folder.Open(reopen())
+				assert.NoError(t, err)
+
+				_, err = f.ApplyBatch([]folder.Operation {
+					{Kind: folder.OpCreate, OrgID: orgID, Name: "A"},
+					{Kind: folder.OpCreate, OrgID: orgID, Path: "A", Name: "B"},
+				})
+				assert.NoError(t, err)
+				tx := f.Begin()
+				_, err = tx.CreateFolder(orgID, "", "C")
+				assert.NoError(t, err)
+				_, err = tx.MoveFolder("B", "C")
+				assert.NoError(t, err)
+
+				reopened, err := folder.Open(reopen())
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"A", "A.B"}, folderPaths(reopened.GetFoldersByOrgID(orgID)))
+
+				assert.NoError(t, tx.Commit())
+				reopened, err = folder.Open(reopen())
+				assert.NoError(t, err)
+				assert.Equal(t, f.Snapshot().Folders(), reopened.Snapshot().Folders())
+			})
+
+			t.Run("Failed save undoes the change", func(t *testing.T) {
+				t.Parallel()
+				reopen := st.backing(t)
+				store := &failingStore{Store: reopen()}
+				f, err := folder.Open(store)
+				assert.NoError(t, err)
+				_, err = f.CreateFolder(orgID, "", "A")
+				assert.NoError(t, err)
+				_, err = f.CreateFolder(orgID, "", "B")
+				assert.NoError(t, err)
+				before := f.Snapshot().Folders()
+
+				store.failing = true
+				_, err = f.MoveFolder("B", "A")
+				assert.EqualError(t, err, "disk full")
+				assert.Equal(t, before, f.Snapshot().Folders())
+				_, err = f.Undo()
+				assert.EqualError(t, err, "disk full")
+				assert.Equal(t, before, f.Snapshot().Folders())
+
+				reopened, err := folder.Open(reopen())
+				assert.NoError(t, err)
+				assert.Equal(t, before, reopened.Snapshot().Folders())
+			})
+		})
+	}
+}
+
+func Test_folder_NewMemoryStore(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	seed := []folder.Folder {
+		{Name: "A", Paths: "A", OrgId: orgID},
+	}
+	store := folder.NewMemoryStore(seed)
+	seed[0].Name = "changed"
+
+	f, err := folder.Open(store)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, folderPaths(f.GetFoldersByOrgID(orgID)))
+	_, err = f.CreateFolder(orgID, "A", "B")
+	assert.NoError(t, err)
+
+	loaded, err := store.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Snapshot().Folders(), loaded)
+}
@@ -0,0 +1,179 @@
+package folder
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/gofrs/uuid"
+)
+
+// ChangeKind tells how a folder was affected by a change.
+type ChangeKind int
+
+const (
+	// ChangeCreated is a new folder, Old is empty.
+	ChangeCreated ChangeKind = iota
+	// ChangeMoved is a folder that kept its name but changed parent, position
+	// or organisation. Folders below a renamed or moved folder are moved too.
+	ChangeMoved
+	// ChangeRenamed is a folder that was given a new name.
+	ChangeRenamed
+	// ChangeDeleted is a removed folder, New is empty.
+	ChangeDeleted
+)
+
+var changeKindNames = [...]string{"created", "moved", "renamed", "deleted"}
+
+func (k ChangeKind) String() string {
+	if k < ChangeCreated || k > ChangeDeleted {
+		return "unknown"
+	}
+	return changeKindNames[k]
+}
+
+// Change is a single folder affected by a change.
+type Change struct {
+	Kind ChangeKind
+	Old  Folder
+	New  Folder
+}
+
+// ChangeEvent is everything a single driver call changed.
+type ChangeEvent struct {
+	Op      string // driver method that made the change, "Undo" or "Redo" included
+	Changes []Change
+}
+
+/*
+Subscription is a feed of ChangeEvents. Events are buffered per subscriber and
+a change never waits for a subscriber: one whose buffer is full when an event
+arrives is dropped, its channel is closed and Err reports ErrSubscriberLagged.
+It can then subscribe again and reload what it needs.
+*/
+type Subscription struct {
+	mu     sync.Mutex
+	events chan ChangeEvent
+	closed bool
+	err    error
+}
+
+func (f *driver) Subscribe(buffer int) *Subscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if buffer < 0 {
+		buffer = 0
+	}
+	sub := &Subscription{events: make(chan ChangeEvent, buffer)}
+	f.subs = append(f.subs, sub)
+	return sub
+}
+
+// Events returns the channel events are delivered on, it is closed once the
+// subscription ends.
+func (s *Subscription) Events() <-chan ChangeEvent {
+	return s.events
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.end(nil)
+}
+
+// Err returns ErrSubscriberLagged if the subscription was dropped for falling
+// behind, nil otherwise.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Subscription) end(err error) {
+	if !s.closed {
+		s.closed = true
+		s.err = err
+		close(s.events)
+	}
+}
+
+/* Delivers an event without blocking, dropping closed and lagging subscribers */
+func (s *Subscription) deliver(event ChangeEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.events <- event:
+		return true
+	default:
+		s.end(errors.New(ErrSubscriberLagged))
+		return false
+	}
+}
+
+/* Closes every subscription, for a driver that won't change any more */
+func (f *driver) endSubs() {
+	for _, sub := range f.subs {
+		sub.Close()
+	}
+	f.subs = nil
+}
+
+/* Sends an event to every subscriber */
+func (f *driver) publish(event ChangeEvent) {
+	subs := f.subs[:0]
+	for _, sub := range f.subs {
+		if sub.deliver(event) {
+			subs = append(subs, sub)
+		}
+	}
+	clear(f.subs[len(subs):])
+	f.subs = subs
+}
+
+/*
+Turns the folders a change touched into Changes. A folder handed to another
+org keeps its ID, so it is a move rather than a delete and a create. Changes
+come by org and in the order of the org's folders, deletions last.
+*/
+func sortedChanges(changes map[uuid.UUID]folderChange) []Change {
+	type sortable struct {
+		change  Change
+		record  *folderRecord // where the folder is now, or was for a deletion
+		deleted bool
+	}
+
+	list := make([]sortable, 0, len(changes))
+	for _, c := range changes {
+		switch {
+		case c.after == nil:
+			list = append(list, sortable{Change{Kind: ChangeDeleted, Old: c.before.folder}, c.before, true})
+		case c.before == nil:
+			list = append(list, sortable{Change{Kind: ChangeCreated, New: c.after.folder}, c.after, false})
+		case c.before.folder.Name != c.after.folder.Name:
+			list = append(list, sortable{Change{Kind: ChangeRenamed, Old: c.before.folder, New: c.after.folder}, c.after, false})
+		default:
+			list = append(list, sortable{Change{Kind: ChangeMoved, Old: c.before.folder, New: c.after.folder}, c.after, false})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.deleted != b.deleted {
+			return b.deleted
+		}
+		if c := compareIDs(a.record.folder.OrgId, b.record.folder.OrgId); c != 0 {
+			return c < 0
+		}
+		return a.record.seq < b.record.seq
+	})
+
+	res := make([]Change, len(list))
+	for i, item := range list {
+		res[i] = item.change
+	}
+	return res
+}
@@ -0,0 +1,125 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_Subscribe(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+	})
+	tests := [...]struct {
+		name string
+		change func(t *testing.T, f folder.IDriver)
+		want []folder.ChangeEvent
+	} {
+		{
+			name: "Move",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.MoveFolder("A", "C")
+				assert.NoError(t, err)
+			},
+			want: []folder.ChangeEvent {
+				{Op: "MoveFolder", Changes: []folder.Change {
+					{Kind: folder.ChangeMoved, Old: folders[0], New: folder.Folder{Id: folders[0].Id, Name: "A", OrgId: orgID, Paths: "C.A", Version: 1}},
+					{Kind: folder.ChangeMoved, Old: folders[1], New: folder.Folder{Id: folders[1].Id, Name: "B", OrgId: orgID, Paths: "C.A.B", Version: 1}},
+				}},
+			},
+		},
+		{
+			name: "Rename",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.RenameFolder(orgID, "A", "Z")
+				assert.NoError(t, err)
+			},
+			want: []folder.ChangeEvent {
+				{Op: "RenameFolder", Changes: []folder.Change {
+					{Kind: folder.ChangeRenamed, Old: folders[0], New: folder.Folder{Id: folders[0].Id, Name: "Z", OrgId: orgID, Paths: "Z", Version: 1}},
+					{Kind: folder.ChangeMoved, Old: folders[1], New: folder.Folder{Id: folders[1].Id, Name: "B", OrgId: orgID, Paths: "Z.B", Version: 1}},
+				}},
+			},
+		},
+		{
+			name: "Delete and undo",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.DeleteFolder(orgID, "A", folder.DeleteRecursive)
+				assert.NoError(t, err)
+				_, err = f.Undo()
+				assert.NoError(t, err)
+			},
+			want: []folder.ChangeEvent {
+				{Op: "DeleteFolder", Changes: []folder.Change {
+					{Kind: folder.ChangeDeleted, Old: folders[0]},
+					{Kind: folder.ChangeDeleted, Old: folders[1]},
+				}},
+				{Op: "Undo", Changes: []folder.Change {
+					{Kind: folder.ChangeCreated, New: folder.Folder{Id: folders[0].Id, Name: "A", OrgId: orgID, Paths: "A", Version: 1}},
+					{Kind: folder.ChangeCreated, New: folder.Folder{Id: folders[1].Id, Name: "B", OrgId: orgID, Paths: "A.B", Version: 1}},
+				}},
+			},
+		},
+		{
+			name: "Failed change sends nothing",
+			change: func(t *testing.T, f folder.IDriver) {
+				_, err := f.MoveFolder("A", "B")
+				assert.Equal(t, errors.New(folder.ErrSourceToChild), err)
+			},
+			want: []folder.ChangeEvent{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+			sub := f.Subscribe(len(tt.want) + 1)
+			tt.change(t, f)
+			sub.Close()
+
+			got := []folder.ChangeEvent{}
+			for event := range sub.Events() {
+				got = append(got, event)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, sub.Err())
+		})
+	}
+}
+
+func Test_folder_Subscribe_Lagging(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+	})
+	slow := f.Subscribe(1)
+	fast := f.Subscribe(1)
+
+	created, err := f.CreateFolder(orgID, "A", "B")
+	assert.NoError(t, err)
+	event := <-fast.Events()
+	assert.Equal(t, []folder.Change{{Kind: folder.ChangeCreated, New: created}}, event.Changes)
+
+	// slow hasn't read its first event, so the second one drops it
+	_, err = f.CreateFolder(orgID, "A", "C")
+	assert.NoError(t, err)
+	assert.Equal(t, errors.New(folder.ErrSubscriberLagged), slow.Err())
+	assert.NoError(t, fast.Err())
+
+	// what was buffered can still be read before the channel reports closed
+	event, ok := <-slow.Events()
+	assert.True(t, ok)
+	assert.Equal(t, "CreateFolder", event.Op)
+	_, ok = <-slow.Events()
+	assert.False(t, ok)
+
+	event = <-fast.Events()
+	assert.Equal(t, "A.C", event.Changes[0].New.Paths)
+}
@@ -0,0 +1,88 @@
+package folder
+
+import (
+	"errors"
+	"sync"
+)
+
+/*
+Tx is a transaction opened with Begin. It is an IDriver of its own, reads see
+the transaction's uncommitted changes while the driver it was opened on keeps
+serving the last committed state. Commit fails if that driver was changed in
+the meantime, by another transaction or directly. Like a database/sql Tx it
+is not meant to be used once committed or rolled back, but Commit and Rollback
+may race, exactly one of them wins.
+
+A transaction has a history and subscribers of its own, covering the changes
+made through it, so Undo only steps back within the transaction. Its commit
+is recorded on the driver as a single change, and ending the transaction
+ends its subscriptions. Like a driver it is safe for concurrent use.
+*/
+type Tx interface {
+	IDriver
+	// Commit makes the transaction's changes visible on the driver it was
+	// opened on.
+	Commit() error
+	// Rollback discards the transaction's changes.
+	Rollback() error
+}
+
+type tx struct {
+	*driver // working copy the transaction's calls read and change, never replaced
+	base    *driver
+	baseRev int
+
+	mu   sync.Mutex // guards done, taken before base.mu
+	done bool
+}
+
+func (f *driver) Begin() Tx {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	working := f.clone()
+	working.historyLimit = f.historyLimit
+	working.pending = newChangeSet()
+	working.origin = newChangeSet()
+	return &tx{driver: working, base: f, baseRev: f.rev}
+}
+
+func (t *tx) Commit() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.base.mu.Lock()
+	defer t.base.mu.Unlock()
+
+	if t.done {
+		return errors.New(ErrTxDone)
+	}
+	if t.base.rev != t.baseRev {
+		return errors.New(ErrTxConflict)
+	}
+	t.done = true
+	defer t.base.record("Commit", &err)
+
+	// the driver takes over the working copy's maps, stray calls must not
+	// reach them, so the working copy gets maps of its own
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.base.adopt(t.driver)
+	detached := t.base.clone()
+	t.driver.orgs, t.driver.ids = detached.orgs, detached.ids
+	t.driver.endSubs()
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return errors.New(ErrTxDone)
+	}
+	t.done = true
+	t.driver.mu.Lock()
+	defer t.driver.mu.Unlock()
+	t.driver.endSubs()
+	return nil
+}
@@ -0,0 +1,190 @@
+package folder_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_Tx(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+	})
+	moved := []folder.Folder {
+		folders[0],
+		{Id: folders[1].Id, Name: "B", OrgId: orgID, Paths: "C.B", Version: 1},
+		folders[2],
+	}
+	tests := [...]struct {
+		name string
+		// runs between the move inside the transaction and ending it
+		interfere func(t *testing.T, f folder.IDriver)
+		rollback bool
+		wantErr error
+		wantFolders []folder.Folder
+	} {
+		{
+			name: "Commit publishes the changes",
+			wantFolders: moved,
+		},
+		{
+			name: "Rollback discards the changes",
+			rollback: true,
+			wantFolders: folders,
+		},
+		{
+			name: "Commit after another change conflicts",
+			interfere: func(t *testing.T, f folder.IDriver) {
+				_, err := f.CreateFolder(orgID, "", "D")
+				assert.NoError(t, err)
+			},
+			wantErr: errors.New(folder.ErrTxConflict),
+			wantFolders: append(append([]folder.Folder{}, folders...),
+				folder.Folder{Name: "D", OrgId: orgID, Paths: "D", Position: 2}),
+		},
+		{
+			name: "Failed change on the driver doesn't conflict",
+			interfere: func(t *testing.T, f folder.IDriver) {
+				_, err := f.MoveFolder("A", "A.B")
+				assert.Error(t, err)
+			},
+			wantFolders: moved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+			tx := f.Begin()
+
+			res, err := tx.MoveFolder("B", "C")
+			assert.NoError(t, err)
+			assert.Equal(t, moved, res)
+			// the transaction reads its own writes, the driver doesn't see them yet
+			child, err := tx.GetAllChildFolders(orgID, "C")
+			assert.NoError(t, err)
+			assert.Equal(t, moved[1:2], child)
+			assert.Equal(t, folders, f.GetFoldersByOrgID(orgID))
+
+			if tt.interfere != nil {
+				tt.interfere(t, f)
+			}
+			if tt.rollback {
+				err = tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+			assert.Equal(t, tt.wantErr, err)
+
+			got := f.GetFoldersByOrgID(orgID)
+			for i := range got {
+				if got[i].Name == "D" {
+					got[i].Id = uuid.Nil // random ID
+				}
+			}
+			assert.Equal(t, tt.wantFolders, got)
+		})
+	}
+}
+
+func Test_folder_Tx_Done(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+	})
+
+	tx := f.Begin()
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, errors.New(folder.ErrTxDone), tx.Commit())
+	assert.Equal(t, errors.New(folder.ErrTxDone), tx.Rollback())
+
+	// a later transaction starts from the committed state
+	tx = f.Begin()
+	_, err := tx.CreateFolder(orgID, "A", "B")
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	assert.Len(t, f.GetFoldersByOrgID(orgID), 2)
+
+	// a committed transaction can't reach the driver anymore
+	_, err = tx.CreateFolder(orgID, "A", "C")
+	assert.NoError(t, err)
+	assert.Len(t, f.GetFoldersByOrgID(orgID), 2)
+}
+
+func Test_folder_Tx_CommitRollbackRace(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder{{Name: "A", OrgId: orgID, Paths: "A"}})
+	tx := f.Begin()
+	_, err := tx.CreateFolder(orgID, "", "B")
+	assert.NoError(t, err)
+
+	// exactly one of them wins, run with -race to check they don't race
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, end := range []func() error{tx.Commit, tx.Rollback} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = end()
+		}()
+	}
+	wg.Wait()
+
+	if errs[0] == nil {
+		assert.Equal(t, errors.New(folder.ErrTxDone), errs[1])
+		assert.Len(t, f.GetFoldersByOrgID(orgID), 2)
+	} else {
+		assert.Equal(t, errors.New(folder.ErrTxDone), errs[0])
+		assert.NoError(t, errs[1])
+		assert.Len(t, f.GetFoldersByOrgID(orgID), 1)
+	}
+}
+
+func Test_folder_Tx_HistoryAndSubscribe(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder{{Name: "A", OrgId: orgID, Paths: "A"}})
+	_, err := f.CreateFolder(orgID, "", "B")
+	assert.NoError(t, err)
+
+	tx := f.Begin()
+	sub := tx.Subscribe(10)
+	_, err = tx.CreateFolder(orgID, "A", "C")
+	assert.NoError(t, err)
+	_, err = tx.RenameFolder(orgID, "B", "D")
+	assert.NoError(t, err)
+	assert.Equal(t, []folder.HistoryEntry {
+		{Op: "CreateFolder", OrgIDs: []uuid.UUID{orgID}},
+		{Op: "RenameFolder", OrgIDs: []uuid.UUID{orgID}},
+	}, tx.History())
+
+	// undo stays within the transaction, the driver's own change is out of reach
+	_, err = tx.Undo()
+	assert.NoError(t, err)
+	_, err = tx.Undo()
+	assert.NoError(t, err)
+	_, err = tx.Undo()
+	assert.Equal(t, errors.New(folder.ErrNothingToUndo), err)
+	_, err = tx.Redo()
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	var ops []string
+	for event := range sub.Events() {
+		ops = append(ops, event.Op)
+	}
+	assert.Equal(t, []string{"CreateFolder", "RenameFolder", "Undo", "Undo", "Redo"}, ops)
+	assert.NoError(t, sub.Err())
+	assert.Equal(t, []string{"A", "B", "A.C"}, folderPaths(f.GetFoldersByOrgID(orgID)))
+	history := f.History()
+	assert.Equal(t, "Commit", history[len(history)-1].Op)
+}
@@ -0,0 +1,112 @@
+package folder
+
+import (
+	"errors"
+
+	"github.com/gofrs/uuid"
+)
+
+// TransferOptions tunes TransferFolder, the zero value fails on a name conflict.
+type TransferOptions struct {
+	// OnConflict decides what happens when dst already holds a folder with the
+	// transferred folder's name.
+	OnConflict ConflictStrategy
+}
+
+// FolderChange is a single folder before and after an operation.
+type FolderChange struct {
+	Old Folder
+	New Folder
+}
+
+// TransferReport lists what a TransferFolder call changed.
+type TransferReport struct {
+	SrcOrgID uuid.UUID
+	DstOrgID uuid.UUID
+	Renamed  bool           // the folder took a new name to avoid a conflict
+	Changes  []FolderChange // every transferred folder, the folder itself first
+}
+
+func (f *driver) TransferFolder(orgID uuid.UUID, src string, dstOrgID uuid.UUID, dst string, opts TransferOptions, writeOpts ...WriteOption) (_ TransferReport, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	defer f.record("TransferFolder", &err)
+
+	if opts.OnConflict < ConflictFail || opts.OnConflict > ConflictCodename {
+		return TransferReport{}, errors.New(ErrUnknownConflictStrategy)
+	}
+	if dstOrgID.IsNil() {
+		return TransferReport{}, errors.New(ErrInvalidOrgID)
+	}
+	if dstOrgID == orgID {
+		return TransferReport{}, errors.New(ErrTransferSameOrg)
+	}
+
+	idx, err := f.orgIndex(orgID)
+	if err != nil {
+		return TransferReport{}, err
+	}
+	source, err := idx.resolvePath(src, ErrSourceNotExists)
+	if err != nil {
+		return TransferReport{}, err
+	}
+	if err := checkVersion(source, writeOpts); err != nil {
+		return TransferReport{}, err
+	}
+	childFolders, err := idx.descendants(source.Paths)
+	if err != nil {
+		return TransferReport{}, err
+	}
+
+	dstIdx, exists := f.orgs[dstOrgID]
+	if !exists {
+		dstIdx = buildOrgIndex(nil, nil)
+	}
+	if dst != "" {
+		if _, err := dstIdx.resolvePath(dst, ErrDestNotExist); err != nil {
+			return TransferReport{}, err
+		}
+	}
+
+	name, err := dstIdx.freeName(dst, source.Name, opts.OnConflict)
+	if err != nil {
+		return TransferReport{}, err
+	}
+	newPath := name
+	if dst != "" {
+		newPath = dst + "." + name
+	}
+
+	report := TransferReport{
+		SrcOrgID: orgID,
+		DstOrgID: dstOrgID,
+		Renamed:  name != source.Name,
+	}
+	moved := source
+	moved.Name = name
+	moved.OrgId = dstOrgID
+	moved.Paths = newPath
+	moved.Position = dstIdx.nextPosition(dst)
+	moved.Version++ // the target org never had the folder, so it can't tell it changed
+	report.Changes = append(report.Changes, FolderChange{Old: source, New: moved})
+	for _, f := range childFolders {
+		moved := f
+		moved.OrgId = dstOrgID
+		moved.Paths = newPath + f.Paths[len(source.Paths):] // keeps the leading '.'
+		moved.Version++
+		report.Changes = append(report.Changes, FolderChange{Old: f, New: moved})
+	}
+
+	// take the subtree out of the source org, then add it to the target org
+	removed := make([]int, 0, len(report.Changes))
+	transferred := make([]Folder, 0, len(report.Changes))
+	for _, change := range report.Changes {
+		seq, _ := idx.byID.Get(change.Old.Id)
+		removed = append(removed, seq)
+		transferred = append(transferred, change.New)
+	}
+	f.setRecords(orgID, nil, removed)
+	f.insertFolders(dstOrgID, transferred)
+
+	return report, nil
+}
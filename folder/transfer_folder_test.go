@@ -0,0 +1,112 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_TransferFolder(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	newOrgID := uuid.Must(uuid.NewV4())
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "A.D", Position: 1},
+		{Name: "X", OrgId: otherOrgID, Paths: "X"},
+		{Name: "B", OrgId: otherOrgID, Paths: "X.B"},
+	})
+	tests := [...]struct {
+		name string
+		src string
+		dstOrgID uuid.UUID
+		dst string
+		opts folder.TransferOptions
+		wantChanges []folder.FolderChange
+		wantRenamed bool
+		wantErr error
+	} {
+		{
+			name: "Transfer subtree under a folder of another org",
+			src: "A.B",
+			dstOrgID: otherOrgID,
+			dst: "X",
+			opts: folder.TransferOptions{OnConflict: folder.ConflictSuffix},
+			wantChanges: []folder.FolderChange {
+				{Old: folders[1], New: folder.Folder{Id: folders[1].Id, Name: "B2", OrgId: otherOrgID, Paths: "X.B2", Position: 1, Version: 1}},
+				{Old: folders[2], New: folder.Folder{Id: folders[2].Id, Name: "C", OrgId: otherOrgID, Paths: "X.B2.C", Version: 1}},
+			},
+			wantRenamed: true,
+		},
+		{
+			name: "Transfer to the top level of a new org",
+			src: "A.B",
+			dstOrgID: newOrgID,
+			wantChanges: []folder.FolderChange {
+				{Old: folders[1], New: folder.Folder{Id: folders[1].Id, Name: "B", OrgId: newOrgID, Paths: "B", Version: 1}},
+				{Old: folders[2], New: folder.Folder{Id: folders[2].Id, Name: "C", OrgId: newOrgID, Paths: "B.C", Version: 1}},
+			},
+		},
+		{
+			name: "Conflict fails by default",
+			src: "A.B",
+			dstOrgID: otherOrgID,
+			dst: "X",
+			wantErr: errors.New(folder.ErrFolderNameConflict + " X.B"),
+		},
+		{
+			name: "Transfer within the same org",
+			src: "A.B",
+			dstOrgID: orgID,
+			wantErr: errors.New(folder.ErrTransferSameOrg),
+		},
+		{
+			name: "Missing destination",
+			src: "A.B",
+			dstOrgID: otherOrgID,
+			dst: "X.Y",
+			wantErr: errors.New(folder.ErrDestNotExist),
+		},
+		{
+			name: "Missing source",
+			src: "A.Z",
+			dstOrgID: otherOrgID,
+			wantErr: errors.New(folder.ErrSourceNotExists),
+		},
+		{
+			name: "Nil target org",
+			src: "A.B",
+			wantErr: errors.New(folder.ErrInvalidOrgID),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+			report, err := f.TransferFolder(orgID, tt.src, tt.dstOrgID, tt.dst, tt.opts)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr != nil {
+				assert.Equal(t, folder.TransferReport{}, report)
+				assert.Equal(t, folders[:4], f.GetFoldersByOrgID(orgID))
+				return
+			}
+			assert.Equal(t, orgID, report.SrcOrgID)
+			assert.Equal(t, tt.dstOrgID, report.DstOrgID)
+			assert.Equal(t, tt.wantRenamed, report.Renamed)
+			assert.Equal(t, tt.wantChanges, report.Changes)
+
+			// the subtree left the source org and kept its IDs in the target org
+			assert.Equal(t, []folder.Folder{folders[0], folders[3]}, f.GetFoldersByOrgID(orgID))
+			for _, change := range tt.wantChanges {
+				got, err := f.GetFolderByID(change.New.Id)
+				assert.NoError(t, err)
+				assert.Equal(t, change.New, got)
+			}
+		})
+	}
+}
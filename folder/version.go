@@ -0,0 +1,136 @@
+package folder
+
+import (
+	"strconv"
+
+	"github.com/gofrs/uuid"
+)
+
+// WriteOption adds a precondition to a mutating call.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	checkVersion  bool
+	expectVersion int
+}
+
+// IfVersion makes a call fail with a *VersionConflictError unless the folder
+// it changes, the source of a move, is still at version. Pass the Version a
+// folder was read with to make sure nobody changed it in the meantime.
+func IfVersion(version int) WriteOption {
+	return func(o *writeOptions) {
+		o.checkVersion = true
+		o.expectVersion = version
+	}
+}
+
+// VersionConflictError reports a folder that changed since the version a
+// caller expected.
+type VersionConflictError struct {
+	Id       uuid.UUID
+	Paths    string
+	Expected int
+	Actual   int
+}
+
+func (e *VersionConflictError) Error() string {
+	return ErrVersionConflict + " " + e.Paths + ", expected version " +
+		strconv.Itoa(e.Expected) + " but it is at " + strconv.Itoa(e.Actual)
+}
+
+/* Checks the preconditions in opts against the folder a call is about to change */
+func checkVersion(folder Folder, opts []WriteOption) error {
+	var o writeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.checkVersion && o.expectVersion != folder.Version {
+		return &VersionConflictError{
+			Id:       folder.Id,
+			Paths:    folder.Paths,
+			Expected: o.expectVersion,
+			Actual:   folder.Version,
+		}
+	}
+	return nil
+}
+
+/* Returns folders as their org's index now holds them, with any bumped Version */
+func (f *driver) refresh(orgID uuid.UUID, folders []Folder) []Folder {
+	idx := f.orgs[orgID]
+	for i, folder := range folders {
+		seq, _ := idx.byID.Get(folder.Id)
+		folders[i] = idx.folder(seq)
+	}
+	return folders
+}
+
+/*
+Bumps the Version of every record that differs from the folder it replaces in
+old, so the mutations themselves never have to keep track. Folders new to the
+org keep the version they come with.
+*/
+func bumpVersions(old *orgIndex, records []folderRecord) {
+	for i, record := range records {
+		prev, exists := old.folders.Get(record.seq)
+		if !exists {
+			continue
+		}
+		record.folder.Version = prev.Version
+		if record.folder != prev {
+			record.folder.Version++
+		}
+		records[i].folder.Version = record.folder.Version
+	}
+}
+
+/*
+Returns the folders of a history entry as they were before the change, or
+after it when redoing, nil for a folder that didn't exist then. Versions keep
+going up. A folder the driver holds keeps its version if it isn't changing
+and goes one above it otherwise. A folder coming back goes one above the
+highest version it has in the history, so a caller holding any version it had
+before can't pass IfVersion.
+*/
+func (f *driver) reversion(entry historyEntry, undo bool) map[uuid.UUID]*folderRecord {
+	records := make(map[uuid.UUID]*folderRecord, len(entry.changes))
+	for id, change := range entry.changes {
+		side := change.after
+		if undo {
+			side = change.before
+		}
+		if side == nil {
+			records[id] = nil
+			continue
+		}
+
+		record := *side
+		if current := f.currentRecord(id); current != nil {
+			record.folder.Version = current.folder.Version
+			if record.folder != current.folder {
+				record.folder.Version++
+			}
+		} else {
+			record.folder.Version = f.highestVersion(id) + 1
+		}
+		records[id] = &record
+	}
+	return records
+}
+
+/* Returns the highest version a folder has in the history, -1 if it has none */
+func (f *driver) highestVersion(id uuid.UUID) int {
+	highest := -1
+	for _, entry := range f.history {
+		change, touched := entry.changes[id]
+		if !touched {
+			continue
+		}
+		for _, record := range []*folderRecord{change.before, change.after} {
+			if record != nil && record.folder.Version > highest {
+				highest = record.folder.Version
+			}
+		}
+	}
+	return highest
+}
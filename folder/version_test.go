@@ -0,0 +1,182 @@
+package folder_test
+
+import (
+	"errors"
+	"testing"
+	"github.com/georgechieng-sc/interns-2022/folder"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_folder_Versions(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "A.B"},
+		{Name: "C", OrgId: orgID, Paths: "A.B.C"},
+		{Name: "D", OrgId: orgID, Paths: "D", Position: 1},
+		{Name: "E", OrgId: orgID, Paths: "E", Position: 2, Version: 7},
+	})
+	versions := func() map[string]int {
+		res := make(map[string]int)
+		for _, folder := range f.GetFoldersByOrgID(orgID) {
+			res[folder.Name] = folder.Version
+		}
+		return res
+	}
+
+	// the whole moved subtree changes, nothing else does
+	_, err := f.MoveFolder("B", "D")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"A": 0, "B": 1, "C": 1, "D": 0, "E": 7}, versions())
+
+	// E shifts along to make room, so it changes as well
+	_, err = f.MoveFolderBefore(orgID, "D.B", "E")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"A": 0, "B": 2, "C": 2, "D": 0, "E": 8}, versions())
+
+	_, err = f.RenameFolder(orgID, "A", "Z")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Z": 1, "B": 2, "C": 2, "D": 0, "E": 8}, versions())
+
+	// undo brings back the folders as they were, but it is a change too
+	_, err = f.Undo()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"A": 2, "B": 2, "C": 2, "D": 0, "E": 8}, versions())
+	_, err = f.Redo()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Z": 3, "B": 2, "C": 2, "D": 0, "E": 8}, versions())
+}
+
+func Test_folder_IfVersionAfterUndo(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	f := folder.NewDriver([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A"},
+		{Name: "B", OrgId: orgID, Paths: "B", Position: 1},
+	})
+	read, err := f.GetFolderByID(folder.DeriveFolderID(orgID, "B"))
+	assert.NoError(t, err)
+
+	// B is back where it was read, but two changes have happened since
+	_, err = f.MoveFolder("B", "A")
+	assert.NoError(t, err)
+	_, err = f.Undo()
+	assert.NoError(t, err)
+	_, err = f.MoveFolder("B", "A", folder.IfVersion(read.Version))
+	assert.Equal(t, &folder.VersionConflictError{Id: read.Id, Paths: "B", Expected: 0, Actual: 2}, err)
+
+	// a folder brought back by undoing its delete can't be mistaken either
+	_, err = f.Redo()
+	assert.NoError(t, err)
+	moved, err := f.GetFolderByID(read.Id)
+	assert.NoError(t, err)
+	_, err = f.DeleteFolder(orgID, "A", folder.DeleteRecursive)
+	assert.NoError(t, err)
+	_, err = f.Undo()
+	assert.NoError(t, err)
+	_, err = f.MoveFolderToRoot(orgID, "A.B", folder.IfVersion(moved.Version))
+	assert.Equal(t, &folder.VersionConflictError{Id: read.Id, Paths: "A.B", Expected: 3, Actual: 4}, err)
+	_, err = f.MoveFolderToRoot(orgID, "A.B", folder.IfVersion(4))
+	assert.NoError(t, err)
+}
+
+func Test_folder_IfVersion(t *testing.T) {
+	t.Parallel()
+	orgID := uuid.FromStringOrNil(folder.DefaultOrgID)
+	otherOrgID := uuid.Must(uuid.NewV4())
+	folders := withIDs([]folder.Folder {
+		{Name: "A", OrgId: orgID, Paths: "A", Version: 3},
+		{Name: "B", OrgId: orgID, Paths: "A.B", Version: 5},
+		{Name: "C", OrgId: orgID, Paths: "C", Position: 1},
+		{Name: "X", OrgId: otherOrgID, Paths: "X"},
+	})
+	conflict := &folder.VersionConflictError{Id: folders[1].Id, Paths: "A.B", Expected: 4, Actual: 5}
+	tests := [...]struct {
+		name string
+		change func(f folder.IDriver, check folder.WriteOption) error
+	} {
+		{
+			name: "MoveFolder",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.MoveFolder("B", "C", check)
+				return err
+			},
+		},
+		{
+			name: "MoveFolderByPath",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.MoveFolderByPath(orgID, "A.B", "C", check)
+				return err
+			},
+		},
+		{
+			name: "MoveFolderByID",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.MoveFolderByID(folders[1].Id, folders[2].Id, check)
+				return err
+			},
+		},
+		{
+			name: "MoveFolderToRoot",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.MoveFolderToRoot(orgID, "A.B", check)
+				return err
+			},
+		},
+		{
+			name: "MoveFolderAfter",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.MoveFolderAfter(orgID, "A.B", "C", check)
+				return err
+			},
+		},
+		{
+			name: "RenameFolder",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.RenameFolder(orgID, "A.B", "Z", check)
+				return err
+			},
+		},
+		{
+			name: "DeleteFolder",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.DeleteFolder(orgID, "A.B", folder.DeleteIfEmpty, check)
+				return err
+			},
+		},
+		{
+			name: "TransferFolder",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.TransferFolder(orgID, "A.B", otherOrgID, "X", folder.TransferOptions{}, check)
+				return err
+			},
+		},
+		{
+			name: "ApplyBatch",
+			change: func(f folder.IDriver, check folder.WriteOption) error {
+				_, err := f.ApplyBatch([]folder.Operation {
+					{Kind: folder.OpMove, OrgID: orgID, Path: "A.B", Dst: "C", Checks: []folder.WriteOption{check}},
+				})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			f := folder.NewDriver(folders)
+
+			err := tt.change(f, folder.IfVersion(4))
+			assert.Equal(t, conflict, err)
+			var versionErr *folder.VersionConflictError
+			assert.True(t, errors.As(err, &versionErr))
+			assert.Equal(t, folder.ErrVersionConflict + " A.B, expected version 4 but it is at 5", err.Error())
+			assert.Equal(t, folders[:3], f.GetFoldersByOrgID(orgID))
+
+			assert.NoError(t, tt.change(f, folder.IfVersion(5)))
+			assert.NotEqual(t, folders[:3], f.GetFoldersByOrgID(orgID))
+		})
+	}
+}
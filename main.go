@@ -25,7 +25,7 @@ func main() {
 	rootFolderString := "noble-vixen" // subject to change
 	childFolders, err := folderDriver.GetAllChildFolders(orgID, rootFolderString)
 	if err != nil {
-		fmt.Println("\n")
+		fmt.Print("\n\n")
 		fmt.Println(err)
 	} else {
 		fmt.Println("\n\n Child folders of " + rootFolderString + ":")
@@ -37,7 +37,7 @@ func main() {
 	destinationName := "fast-watchmen"
 	switchedFolders, err := folderDriver.MoveFolder(sourceName, destinationName)
 	if err != nil {
-		fmt.Println("\n")
+		fmt.Print("\n\n")
 		fmt.Println(err)
 	} else {
 		fmt.Println("Switched folders")